@@ -3,19 +3,36 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"microseed/internal/app"
 	"microseed/internal/config"
 	"microseed/internal/db"
 	"microseed/internal/migrate"
 	"microseed/internal/seed"
+	"microseed/internal/traffic"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
+// stopMargin covers the OnStop hooks that run after the HTTP server / worker.
+const stopMargin = 5 * time.Second
+
+// stopTimeout replaces fx's default 15s so SHUTDOWN_DRAIN_DELAY does not eat
+// into GRACEFUL_TIMEOUT: the OnStop hooks share one deadline.
+func stopTimeout() fx.Option {
+	cfg, err := config.New()
+	if err != nil {
+		return fx.Error(err)
+	}
+	return fx.StopTimeout(cfg.ShutdownDrainDelay + cfg.GracefulTimeout + stopMargin)
+}
+
 func main() {
 	root := &cobra.Command{
 		Use:   "microseed",
@@ -27,7 +44,16 @@ func main() {
 		Use:   "serve",
 		Short: "Run HTTP API server",
 		Run: func(cmd *cobra.Command, args []string) {
-			fx.New(app.Module).Run()
+			fx.New(app.Module, stopTimeout()).Run()
+		},
+	}
+
+	// worker
+	workerCmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Run background job worker",
+		Run: func(cmd *cobra.Command, args []string) {
+			fx.New(app.WorkerModule, stopTimeout()).Run()
 		},
 	}
 
@@ -84,7 +110,53 @@ func main() {
 		},
 	}
 
-	root.AddCommand(serveCmd, migrateCmd, seedCmd)
+	// replay
+	var (
+		replayFile, replayTarget string
+		replayOpts               traffic.ReplayOptions
+		replayHeaders            []string
+	)
+	replayCmd := &cobra.Command{
+		Use: "replay", Short: "Replay recorded HTTP traffic and diff the responses",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(replayFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			replayOpts.Target = replayTarget
+			replayOpts.Ignore = append(replayOpts.Ignore, traffic.DefaultIgnore...)
+			replayOpts.Headers = http.Header{}
+			for _, h := range replayHeaders {
+				k, v, ok := strings.Cut(h, ":")
+				if !ok {
+					return fmt.Errorf("invalid --header %q, want \"Name: value\"", h)
+				}
+				replayOpts.Headers.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+			}
+			replayOpts.Out = cmd.OutOrStdout()
+			sum, err := traffic.Replay(cmd.Context(), f, replayOpts)
+			if sum != nil {
+				sum.Print(cmd.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+			if !sum.Ok() {
+				return fmt.Errorf("replay: %d mismatched, %d failed", sum.Mismatched, sum.Failed)
+			}
+			return nil
+		},
+	}
+	replayCmd.Flags().StringVar(&replayFile, "file", "logs/traffic.jsonl", "recorded traffic (TRAFFIC_RECORD_FILE)")
+	replayCmd.Flags().StringVar(&replayTarget, "target", "http://localhost:8080", "base URL to replay against")
+	replayCmd.Flags().IntVar(&replayOpts.Concurrency, "concurrency", 4, "parallel requests")
+	replayCmd.Flags().Float64Var(&replayOpts.Rate, "rate", 0, "requests per second (0 = unlimited)")
+	replayCmd.Flags().DurationVar(&replayOpts.Timeout, "timeout", 10*time.Second, "per-request timeout")
+	replayCmd.Flags().StringSliceVar(&replayOpts.Ignore, "ignore", nil, "extra JSON fields to ignore in the diff")
+	replayCmd.Flags().StringArrayVar(&replayHeaders, "header", nil, `header set on every request, e.g. "Authorization: Bearer ..."`)
+
+	root.AddCommand(serveCmd, workerCmd, migrateCmd, seedCmd, replayCmd)
 
 	if err := root.Execute(); err != nil {
 		fmt.Println(err)
@@ -8,10 +8,21 @@ import (
 	"microseed/internal/db"
 	"microseed/internal/domain/health"
 	"microseed/internal/domain/user"
+	"microseed/internal/domain/webhook"
+	"microseed/internal/events"
 	"microseed/internal/httpx"
+	"microseed/internal/idempotency"
+	"microseed/internal/jobs"
+	"microseed/internal/lifecycle"
 	applog "microseed/internal/log"
+	"microseed/internal/messaging"
 	"microseed/internal/obs"
+	"microseed/internal/outbox"
+	"microseed/internal/ratelimit"
+	"microseed/internal/scheduler"
 	"microseed/internal/server"
+	"microseed/internal/stream"
+	"microseed/internal/traffic"
 
 	"go.uber.org/fx"
 )
@@ -30,10 +41,13 @@ func provideLogger(cfg *config.Config) (*zap.Logger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return lg.With(
+	lg = lg.With(
 		zap.String("service", cfg.AppName),
 		zap.String("env", cfg.OTelEnv),
-	), nil
+	)
+	// fallback untuk log.FromContext di luar request
+	zap.ReplaceGlobals(lg)
+	return lg, nil
 }
 
 func loggerHook(lc fx.Lifecycle, lg *zap.Logger) {
@@ -45,28 +59,78 @@ func loggerHook(lc fx.Lifecycle, lg *zap.Logger) {
 	})
 }
 
-var Module = fx.Options(
-	// Infra
+// Infra : shared by every process (serve, worker).
+var Infra = fx.Options(
 	fx.Provide(
 		config.New,
 		provideLogger,
 		obs.New,
 		db.NewGorm,
 		cache.NewRedis,
-		httpx.NewRouter,
-		server.NewHTTP,
+		cache.NewAvailability,
+		cache.New,
+		cache.NewCodec,
+		cache.NewLocker,
 	),
 	fx.Invoke(
-		server.RegisterHooks,
+		obs.RegisterHooks,
 		cache.RegisterHooks,
+		cache.RegisterCacheHooks,
 		db.RegisterHooks,
 		loggerHook,
 	),
+	jobs.Module,
+	events.Module,
+	stream.Module,
+	messaging.Module,
+	idempotency.Module,
+	lifecycle.Module,
+)
 
-	// Routes auto-register
-	httpx.RoutesModule,
+// Background : loops that may run in serve and worker alike
+// (leader election / SKIP LOCKED keep them safe across replicas).
+var Background = fx.Options(
+	scheduler.Module,
+	outbox.Module,
+	stream.ConsumerModule,
+	messaging.ConsumerModule,
+)
 
-	// Feature modules (bounded contexts)
+// Domains : feature modules (bounded contexts).
+var Domains = fx.Options(
 	health.Module,
 	user.Module,
+	user.CachedModule,
+	webhook.Module,
+)
+
+// Module : HTTP API (microseed serve).
+var Module = fx.Options(
+	Infra,
+	fx.Provide(
+		ratelimit.New,
+		httpx.NewRouter,
+		server.NewHTTP,
+	),
+	// sebelum server: recorder di-flush setelah server berhenti
+	traffic.Module,
+	fx.Invoke(server.RegisterHooks),
+
+	// Routes auto-register
+	httpx.RoutesModule,
+
+	Background,
+	Domains,
+
+	// harus paling akhir: startup selesai setelah semua OnStart,
+	// readiness gagal duluan saat shutdown
+	fx.Invoke(lifecycle.RegisterHooks),
+)
+
+// WorkerModule : background job processor (microseed worker).
+var WorkerModule = fx.Options(
+	Infra,
+	jobs.WorkerModule,
+	Background,
+	Domains,
 )
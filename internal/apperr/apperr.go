@@ -0,0 +1,192 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type Kind string
+
+const (
+	KindNotFound      Kind = "not_found"
+	KindConflict      Kind = "conflict"
+	KindValidation    Kind = "validation"
+	KindUnprocessable Kind = "unprocessable"
+	KindUnauthorized  Kind = "unauthorized"
+	KindForbidden     Kind = "forbidden"
+	KindRateLimited   Kind = "rate_limited"
+	KindTimeout       Kind = "timeout"
+	KindCanceled      Kind = "canceled"
+	KindInternal      Kind = "internal"
+)
+
+// StatusClientClosedRequest (nginx's 499) : the client went away before the
+// response was ready. It is not a server failure, so it is not logged as one.
+const StatusClientClosedRequest = 499
+
+var statuses = map[Kind]int{
+	KindNotFound:      http.StatusNotFound,
+	KindConflict:      http.StatusConflict,
+	KindValidation:    http.StatusBadRequest,
+	KindUnprocessable: http.StatusUnprocessableEntity,
+	KindUnauthorized:  http.StatusUnauthorized,
+	KindForbidden:     http.StatusForbidden,
+	KindRateLimited:   http.StatusTooManyRequests,
+	KindTimeout:       http.StatusGatewayTimeout,
+	KindCanceled:      StatusClientClosedRequest,
+	KindInternal:      http.StatusInternalServerError,
+}
+
+// FieldError : one invalid input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error safe to show to clients: Message is public, Err (the
+// cause) is only logged.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	Err     error
+
+	stack []uintptr
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+func (e *Error) Status() int {
+	if s, ok := statuses[e.Kind]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// Stack : where an internal error was created (empty for other kinds).
+func (e *Error) Stack() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+func New(kind Kind, msg string) *Error { return &Error{Kind: kind, Message: msg} }
+
+func NotFound(msg string) *Error     { return New(KindNotFound, def(msg, "not found")) }
+func Conflict(msg string) *Error     { return New(KindConflict, def(msg, "conflict")) }
+func Unauthorized(msg string) *Error { return New(KindUnauthorized, def(msg, "unauthorized")) }
+func Forbidden(msg string) *Error    { return New(KindForbidden, def(msg, "forbidden")) }
+func RateLimited(msg string) *Error  { return New(KindRateLimited, def(msg, "rate limit exceeded")) }
+
+func Validation(msg string, fields ...FieldError) *Error {
+	e := New(KindValidation, def(msg, "invalid request"))
+	e.Fields = fields
+	return e
+}
+
+// Internal wraps an unexpected error; the cause and stack are logged, the
+// client only sees "internal error".
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Message: "internal error", Err: err, stack: callers(3)}
+}
+
+// Wrap attaches a cause to e (logged, never rendered).
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// From maps any error onto an *Error: apperr errors pass through,
+// gorm.ErrRecordNotFound -> NotFound, unique violations (23505) -> Conflict,
+// context deadlines -> Timeout, cancellation -> Canceled, validator
+// errors -> Validation, the rest -> Internal.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var pgErr *pgconn.PgError
+	var verrs validator.ValidationErrors
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return NotFound("").Wrap(err)
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return Conflict("already exists").Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(KindTimeout, "request timed out").Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return New(KindCanceled, "request canceled").Wrap(err)
+	case errors.As(err, &verrs):
+		return Validation("", fieldErrors(verrs)...).Wrap(err)
+	}
+	return &Error{Kind: KindInternal, Message: "internal error", Err: err, stack: callers(3)}
+}
+
+// Invalid : request binding failure (bad JSON or failed `binding` tags).
+func Invalid(err error) *Error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		return Validation("", fieldErrors(verrs)...).Wrap(err)
+	}
+	return Validation("invalid body").Wrap(err)
+}
+
+func fieldErrors(verrs validator.ValidationErrors) []FieldError {
+	out := make([]FieldError, len(verrs))
+	for i, fe := range verrs {
+		msg := "failed " + fe.Tag()
+		switch fe.Tag() {
+		case "required":
+			msg = "is required"
+		case "email":
+			msg = "must be a valid email"
+		case "http_url", "url":
+			msg = "must be a valid URL"
+		case "min":
+			msg = "must have at least " + fe.Param() + " item(s)"
+		}
+		out[i] = FieldError{Field: fe.Field(), Message: msg}
+	}
+	return out
+}
+
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(skip, pcs)]
+}
+
+func def(s, d string) string {
+	if s == "" {
+		return d
+	}
+	return s
+}
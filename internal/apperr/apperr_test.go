@@ -0,0 +1,40 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestFrom(t *testing.T) {
+	cases := []struct {
+		err    error
+		kind   Kind
+		status int
+		stack  bool
+	}{
+		{gorm.ErrRecordNotFound, KindNotFound, http.StatusNotFound, false},
+		{Conflict(""), KindConflict, http.StatusConflict, false},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), KindTimeout, http.StatusGatewayTimeout, false},
+		{fmt.Errorf("query: %w", context.Canceled), KindCanceled, StatusClientClosedRequest, false},
+		{errors.New("boom"), KindInternal, http.StatusInternalServerError, true},
+	}
+	for _, tc := range cases {
+		e := From(tc.err)
+		if e.Kind != tc.kind || e.Status() != tc.status || (e.Stack() != "") != tc.stack {
+			t.Errorf("From(%v) = %s %d stack=%v, want %s %d stack=%v",
+				tc.err, e.Kind, e.Status(), e.Stack() != "", tc.kind, tc.status, tc.stack)
+		}
+	}
+}
+
+func TestCanceledProblem(t *testing.T) {
+	p := From(context.Canceled).Problem("/v1/users", "")
+	if p.Status != 499 || p.Title != "Client Closed Request" || p.Code != KindCanceled {
+		t.Errorf("problem = %+v", p)
+	}
+}
@@ -0,0 +1,37 @@
+package apperr
+
+import "net/http"
+
+const ProblemContentType = "application/problem+json"
+
+// Problem : RFC 7807 body. Code mirrors Kind so clients can switch on it
+// without parsing Title.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Kind         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Problem renders e for clients; the cause (Err) never leaves the process.
+func (e *Error) Problem(instance, requestID string) Problem {
+	status := e.Status()
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	return Problem{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Kind,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+}
@@ -0,0 +1,53 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Set at build time, e.g.
+// go build -ldflags "-X microseed/internal/buildinfo.Version=v1.2.3 -X microseed/internal/buildinfo.Commit=abc123"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+var startedAt = time.Now()
+
+type Info struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	BuildTime string    `json:"build_time,omitempty"`
+	GoVersion string    `json:"go_version"`
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+}
+
+// Get returns the build metadata; Commit falls back to the VCS revision
+// embedded by the Go toolchain.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		StartedAt: startedAt,
+		Uptime:    Uptime().Round(time.Second).String(),
+	}
+	if info.Commit == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" {
+					info.Commit = s.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+func Uptime() time.Duration {
+	return time.Since(startedAt)
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"microseed/pkg/backoff"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	probeTimeout = 500 * time.Millisecond
+	probeEvery   = 5 * time.Second
+)
+
+// Availability tracks whether Redis is reachable. Features that can live
+// without Redis consult Available() and fall back (cache miss, in-memory
+// rate limiting, locks fail closed) instead of erroring on every call.
+type Availability struct {
+	rdb redis.UniversalClient
+	log *zap.Logger
+
+	up        atomic.Bool
+	mu        sync.Mutex
+	known     bool // at least one probe done
+	downSince time.Time
+	listeners []func(up bool)
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewAvailability(rdb redis.UniversalClient, log *zap.Logger) *Availability {
+	return &Availability{rdb: rdb, log: log.Named("redis")}
+}
+
+func (a *Availability) Available() bool { return a.up.Load() }
+
+// OnChange registers fn to be called on every up/down transition.
+func (a *Availability) OnChange(fn func(up bool)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.listeners = append(a.listeners, fn)
+}
+
+// Check pings Redis once, records the result and logs transitions.
+func (a *Availability) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	err := a.rdb.Ping(ctx).Err()
+	a.set(err == nil, err)
+	return err
+}
+
+func (a *Availability) set(up bool, err error) {
+	a.mu.Lock()
+	known := a.known
+	a.known = true
+	if known && a.up.Load() == up {
+		a.mu.Unlock()
+		return
+	}
+	a.up.Store(up)
+	if up {
+		if known {
+			a.log.Info("redis available again", zap.Duration("down_for", time.Since(a.downSince)))
+		}
+	} else {
+		a.downSince = time.Now()
+		a.log.Warn("redis unavailable", zap.Error(err))
+	}
+	listeners := a.listeners
+	a.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(up)
+	}
+}
+
+// Start probes Redis in the background: every probeEvery while it is up,
+// with exponential backoff (capped at 30s) while it is down.
+func (a *Availability) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		attempt := 0
+		for {
+			wait := probeEvery
+			if !a.Available() {
+				attempt++
+				wait = backoff.Exponential(attempt, 500*time.Millisecond, 30*time.Second)
+			} else {
+				attempt = 0
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			_ = a.Check(ctx)
+		}
+	}()
+}
+
+func (a *Availability) Stop() {
+	if a.cancel == nil {
+		return
+	}
+	a.cancel()
+	a.wg.Wait()
+}
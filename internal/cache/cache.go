@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"microseed/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var ErrMiss = errors.New("cache: miss")
+
+// Cache stores raw bytes under namespaced keys; use Typed for values.
+// A ttl of 0 means no expiry.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error) // ErrMiss when absent
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Filler is implemented by caches that treat a read-through fill (a value
+// just loaded from the source of truth) differently from a write. Typed
+// uses it in GetOrLoad and Fill; other caches get a plain Set.
+type Filler interface {
+	Fill(ctx context.Context, key string, val []byte, ttl time.Duration) error
+}
+
+// New returns the Cache selected by CACHE_DRIVER. Keys are namespaced by
+// APP_NAME so several services can share one Redis.
+func New(cfg *config.Config, rdb redis.UniversalClient, avail *Availability, log *zap.Logger) (Cache, error) {
+	prefix := cfg.AppName + ":cache:"
+	switch cfg.CacheDriver {
+	case "", "redis":
+		return NewRedisCache(rdb, avail, prefix), nil
+	case "memory":
+		return NewMemory(cfg.CacheMaxEntries, cfg.CacheMaxBytes), nil
+	case "tiered":
+		l1 := NewMemory(cfg.CacheMaxEntries, cfg.CacheMaxBytes)
+		return NewTiered(l1, NewRedisCache(rdb, avail, prefix), rdb, prefix+"invalidate", cfg.CacheL1TTL, log), nil
+	default:
+		return nil, fmt.Errorf("cache: unknown driver %q", cfg.CacheDriver)
+	}
+}
+
+// RegisterCacheHooks runs the invalidation subscriber of a Tiered cache.
+func RegisterCacheHooks(lc fx.Lifecycle, c Cache) {
+	t, ok := c.(*Tiered)
+	if !ok {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			t.Start()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			t.Stop()
+			return nil
+		},
+	})
+}
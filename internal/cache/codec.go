@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"microseed/internal/config"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+var (
+	JSON    Codec = jsonCodec{}
+	Msgpack Codec = msgpackCodec{} // smaller and faster; values must round-trip through msgpack tags
+)
+
+// NewCodec returns the Codec selected by CACHE_CODEC.
+func NewCodec(cfg *config.Config) (Codec, error) {
+	switch cfg.CacheCodec {
+	case "", "json":
+		return JSON, nil
+	case "msgpack":
+		return Msgpack, nil
+	default:
+		return nil, fmt.Errorf("cache: unknown codec %q", cfg.CacheCodec)
+	}
+}
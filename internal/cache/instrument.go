@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	applog "microseed/internal/log"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const maxStatementLen = 256
+
+// Blocking reads wait on purpose; they are never reported as slow.
+var blockingCommands = map[string]bool{
+	"blpop": true, "brpop": true, "brpoplpush": true, "blmove": true, "blmpop": true,
+	"bzpopmin": true, "bzpopmax": true, "bzmpop": true, "xread": true, "xreadgroup": true,
+}
+
+// redisHook traces, measures and slow-logs every command. Spans are only
+// started below an existing span, so background polling (BRPOP, pings)
+// doesn't flood the tracer with root spans.
+type redisHook struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+	slow     time.Duration
+	log      *zap.Logger
+}
+
+func instrumentRedis(rdb redis.UniversalClient, slow time.Duration, log *zap.Logger) error {
+	meter := otel.Meter("microseed/redis")
+	h := &redisHook{
+		tracer: otel.Tracer("microseed/redis"),
+		slow:   slow,
+		log:    log.Named("redis"),
+	}
+	var err error
+	if h.duration, err = meter.Float64Histogram("redis.command.duration",
+		metric.WithDescription("Redis command latency"),
+		metric.WithUnit("ms")); err != nil {
+		return err
+	}
+	if h.errors, err = meter.Int64Counter("redis.command.errors",
+		metric.WithDescription("Failed Redis commands (redis.Nil excluded)")); err != nil {
+		return err
+	}
+	if err := registerPoolMetrics(meter, rdb); err != nil {
+		return err
+	}
+	rdb.AddHook(h)
+	return nil
+}
+
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.errors.Add(ctx, 1, metric.WithAttributes(attribute.String("command", "dial")))
+		}
+		return conn, err
+	}
+}
+
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := cmd.Name()
+		stmt := func() string { return statement(cmd) }
+		ctx, span := h.start(ctx, "redis "+name, stmt, 1)
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.finish(ctx, span, name, stmt, time.Since(start), err)
+		return err
+	}
+}
+
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		stmt := func() string {
+			stmts := make([]string, len(cmds))
+			for i, cmd := range cmds {
+				stmts[i] = statement(cmd)
+			}
+			return truncate(strings.Join(stmts, "\n"))
+		}
+		ctx, span := h.start(ctx, "redis pipeline", stmt, len(cmds))
+		start := time.Now()
+		err := next(ctx, cmds)
+		if err == nil {
+			// MULTI/EXEC reports per-command failures on the commands
+			for _, cmd := range cmds {
+				if cmd.Err() != nil && !errors.Is(cmd.Err(), redis.Nil) {
+					err = cmd.Err()
+					break
+				}
+			}
+		}
+		h.finish(ctx, span, "pipeline", stmt, time.Since(start), err)
+		return err
+	}
+}
+
+func (h *redisHook) start(ctx context.Context, spanName string, stmt func() string, n int) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil
+	}
+	return h.tracer.Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.statement", stmt()),
+			attribute.Int("db.redis.num_cmd", n),
+		),
+	)
+}
+
+func (h *redisHook) finish(ctx context.Context, span trace.Span, name string, stmt func() string, d time.Duration, err error) {
+	failed := err != nil && !errors.Is(err, redis.Nil)
+	attrs := metric.WithAttributes(attribute.String("command", name))
+	h.duration.Record(ctx, float64(d)/float64(time.Millisecond), attrs)
+	if failed {
+		h.errors.Add(ctx, 1, attrs)
+	}
+	if span != nil {
+		if failed {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+	if h.slow > 0 && d >= h.slow && !blockingCommands[name] {
+		fields := []zap.Field{
+			zap.String("command", name),
+			zap.String("statement", stmt()),
+			zap.Duration("duration", d),
+			zap.String("request_id", applog.RequestID(ctx)),
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			fields = append(fields, zap.String("trace_id", sc.TraceID().String()))
+		}
+		if failed {
+			fields = append(fields, zap.Error(err))
+		}
+		h.log.Warn("slow redis command", fields...)
+	}
+}
+
+// statement keeps the command and its key but masks every other argument,
+// so values (tokens, payloads) never reach traces or logs. Scripts keep
+// their key count and keys; commands that may carry credentials keep none.
+func statement(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) == 0 {
+		return ""
+	}
+	parts := []string{strings.ToUpper(cmd.Name())}
+	keep := 2 // name + key
+	switch cmd.Name() {
+	case "auth", "hello", "config", "migrate", "acl":
+		keep = 1
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		keep = 3 // name + script/sha + numkeys
+		if len(args) > 2 {
+			if n, ok := args[2].(int); ok {
+				keep += n
+			}
+		}
+	}
+	for i := 1; i < len(args); i++ {
+		if i < keep {
+			parts = append(parts, fmt.Sprint(args[i]))
+		} else {
+			parts = append(parts, "?")
+		}
+	}
+	return truncate(strings.Join(parts, " "))
+}
+
+func truncate(s string) string {
+	if len(s) > maxStatementLen {
+		return s[:maxStatementLen] + "..."
+	}
+	return s
+}
+
+func registerPoolMetrics(meter metric.Meter, rdb redis.UniversalClient) error {
+	total, err := meter.Int64ObservableGauge("redis.pool.connections",
+		metric.WithDescription("Open connections in the pool"))
+	if err != nil {
+		return err
+	}
+	idle, err := meter.Int64ObservableGauge("redis.pool.idle",
+		metric.WithDescription("Idle connections in the pool"))
+	if err != nil {
+		return err
+	}
+	hits, err := meter.Int64ObservableCounter("redis.pool.hits",
+		metric.WithDescription("Times a free connection was found in the pool"))
+	if err != nil {
+		return err
+	}
+	misses, err := meter.Int64ObservableCounter("redis.pool.misses",
+		metric.WithDescription("Times a new connection had to be dialed"))
+	if err != nil {
+		return err
+	}
+	timeouts, err := meter.Int64ObservableCounter("redis.pool.timeouts",
+		metric.WithDescription("Times waiting for a connection timed out"))
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		s := rdb.PoolStats()
+		o.ObserveInt64(total, int64(s.TotalConns))
+		o.ObserveInt64(idle, int64(s.IdleConns))
+		o.ObserveInt64(hits, int64(s.Hits))
+		o.ObserveInt64(misses, int64(s.Misses))
+		o.ObserveInt64(timeouts, int64(s.Timeouts))
+		return nil
+	}, total, idle, hits, misses, timeouts)
+	return err
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStatementMasksValues(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		cmd  redis.Cmder
+		want string
+	}{
+		{redis.NewStringCmd(ctx, "get", "user:1"), "GET user:1"},
+		{redis.NewStatusCmd(ctx, "set", "session:1", "token", "px", 1000), "SET session:1 ? ? ?"},
+		{redis.NewCmd(ctx, "evalsha", "abc123", 2, "k1", "k2", "secret"), "EVALSHA abc123 2 k1 k2 ?"},
+		{redis.NewStatusCmd(ctx, "ping"), "PING"},
+	}
+	for _, tt := range tests {
+		if got := statement(tt.cmd); got != tt.want {
+			t.Errorf("statement = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestStatementMasksCredentials(t *testing.T) {
+	ctx := context.Background()
+	cmds := []redis.Cmder{
+		redis.NewStatusCmd(ctx, "auth", "pw"),
+		redis.NewStatusCmd(ctx, "AUTH", "user", "pw"),
+		redis.NewMapStringInterfaceCmd(ctx, "hello", 3, "auth", "user", "pw"),
+		redis.NewStatusCmd(ctx, "config", "set", "requirepass", "pw"),
+		redis.NewStatusCmd(ctx, "config", "set", "masterauth", "pw"),
+		redis.NewStatusCmd(ctx, "migrate", "host", 6379, "", 0, 1000, "auth", "pw", "keys", "k"),
+		redis.NewStatusCmd(ctx, "acl", "setuser", "user", ">pw"),
+	}
+	for _, cmd := range cmds {
+		got := statement(cmd)
+		if strings.Contains(got, "pw") || strings.Contains(got, "user") {
+			t.Errorf("statement(%v) = %q leaks an argument", cmd.Args(), got)
+		}
+		if !strings.HasPrefix(got, strings.ToUpper(cmd.Name())+" ?") {
+			t.Errorf("statement(%v) = %q", cmd.Args(), got)
+		}
+	}
+}
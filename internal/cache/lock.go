@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/pkg/backoff"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotAcquired     = errors.New("cache: lock held elsewhere")
+	ErrLockLost        = errors.New("cache: lock lost")
+	ErrLockUnavailable = errors.New("cache: lock backend unavailable")
+)
+
+// Locker hands out exclusive leases on keys across replicas.
+type Locker interface {
+	// Acquire waits (with backoff) until the lock is free or ctx is done.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error)
+	// TryAcquire returns ErrNotAcquired right away when the lock is held.
+	TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error)
+}
+
+func NewLocker(cfg *config.Config, rdb redis.UniversalClient, avail *Availability, gdb *gorm.DB) (Locker, error) {
+	switch cfg.LockDriver {
+	case "", "redis":
+		return NewRedisLocker(rdb, avail, cfg.AppName+":lock:"), nil
+	case "postgres":
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresLocker(sqlDB), nil
+	default:
+		return nil, fmt.Errorf("cache: unknown lock driver %q", cfg.LockDriver)
+	}
+}
+
+const defaultLeaseTTL = 30 * time.Second
+
+func leaseTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return defaultLeaseTTL
+	}
+	return ttl
+}
+
+// leaseBackend is what a Lease needs from the store that granted it.
+type leaseBackend interface {
+	// renew extends the lease; false means it is no longer ours.
+	renew(ctx context.Context) (bool, error)
+	release(ctx context.Context) error
+}
+
+// Lease is a held lock. It renews itself every ttl/3 until released; if
+// renewal fails for a whole ttl (or the lock was taken over) Done is
+// closed and the holder must stop working.
+//
+// Token is a fencing token that strictly increases per key: pass it along
+// with writes so the store can reject a holder that lost the lock.
+type Lease struct {
+	Key   string
+	Token int64
+
+	b    leaseBackend
+	ttl  time.Duration
+	done chan struct{}
+	stop chan struct{}
+	lost atomic.Bool
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func newLease(key string, token int64, ttl time.Duration, b leaseBackend) *Lease {
+	l := &Lease{
+		Key:   key,
+		Token: token,
+		b:     b,
+		ttl:   ttl,
+		done:  make(chan struct{}),
+		stop:  make(chan struct{}),
+	}
+	l.wg.Add(1)
+	go l.renewLoop()
+	return l
+}
+
+// Done is closed once the lease is lost or released.
+func (l *Lease) Done() <-chan struct{} { return l.done }
+
+// Lost reports whether the lease expired or was taken over.
+func (l *Lease) Lost() bool { return l.lost.Load() }
+
+// Release stops renewal and frees the lock. It returns ErrLockLost when
+// the lease had already been lost.
+func (l *Lease) Release(ctx context.Context) error {
+	first := false
+	l.once.Do(func() {
+		first = true
+		close(l.stop)
+	})
+	l.wg.Wait()
+	if !first {
+		return nil
+	}
+	if l.lost.Load() {
+		return ErrLockLost
+	}
+	return l.b.release(ctx)
+}
+
+func (l *Lease) renewLoop() {
+	defer l.wg.Done()
+	defer close(l.done)
+
+	every := l.ttl / 3
+	t := time.NewTicker(every)
+	defer t.Stop()
+	lastOK := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-t.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), every)
+		ok, err := l.b.renew(ctx)
+		cancel()
+		switch {
+		case err == nil && ok:
+			lastOK = time.Now()
+		case err == nil || time.Since(lastOK) >= l.ttl:
+			l.lost.Store(true)
+			return
+		}
+	}
+}
+
+// acquire retries try until it succeeds, fails with something other than
+// ErrNotAcquired, or ctx is done.
+func acquire(ctx context.Context, try func(context.Context) (*Lease, error)) (*Lease, error) {
+	for attempt := 1; ; attempt++ {
+		l, err := try(ctx)
+		if !errors.Is(err, ErrNotAcquired) {
+			return l, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff.Exponential(attempt, 25*time.Millisecond, 2*time.Second)):
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"microseed/internal/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+	sqlitedrv "github.com/glebarez/go-sqlite"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SQLite stand-ins for the Postgres advisory lock functions. Unlike the
+// real ones they are not tied to a session, which is enough to exercise the
+// locker's own bookkeeping. failUnlock makes pg_advisory_unlock fail.
+var (
+	advisory   sync.Map
+	failUnlock atomic.Bool
+)
+
+func init() {
+	sqlitedrv.MustRegisterScalarFunction("pg_try_advisory_lock", 1, func(_ *sqlitedrv.FunctionContext, args []driver.Value) (driver.Value, error) {
+		_, held := advisory.LoadOrStore(args[0], struct{}{})
+		return !held, nil
+	})
+	sqlitedrv.MustRegisterScalarFunction("pg_advisory_unlock", 1, func(_ *sqlitedrv.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if failUnlock.Load() {
+			return nil, errors.New("unlock failed")
+		}
+		_, held := advisory.LoadAndDelete(args[0])
+		return held, nil
+	})
+}
+
+// criticalSections runs 8 workers taking the lock 5 times each and returns
+// the most holders seen at once and the fencing tokens in grant order.
+func criticalSections(t *testing.T, l Locker) (int32, []int64) {
+	t.Helper()
+	ctx := context.Background()
+	var held, maxHeld atomic.Int32
+	var mu sync.Mutex
+	var tokens []int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				lease, err := l.Acquire(ctx, "job", time.Second)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := held.Add(1)
+				for {
+					m := maxHeld.Load()
+					if n <= m || maxHeld.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				mu.Lock()
+				tokens = append(tokens, lease.Token)
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				held.Add(-1)
+				if err := lease.Release(ctx); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if len(tokens) != 40 {
+		t.Fatalf("%d critical sections ran, want 40", len(tokens))
+	}
+	return maxHeld.Load(), tokens
+}
+
+func checkExclusive(t *testing.T, l Locker) {
+	t.Helper()
+	maxHeld, tokens := criticalSections(t, l)
+	if maxHeld != 1 {
+		t.Errorf("%d holders at once, want 1", maxHeld)
+	}
+	// granted in lock order, so fencing tokens are strictly increasing
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i] <= tokens[i-1] {
+			t.Fatalf("tokens not increasing: %v", tokens)
+		}
+	}
+}
+
+func newTestRedisLocker(t *testing.T) (*RedisLocker, *miniredis.Miniredis) {
+	t.Helper()
+	mr, rdb := testutil.Redis(t)
+	avail := NewAvailability(rdb, zap.NewNop())
+	if err := avail.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewRedisLocker(rdb, avail, "app:lock:"), mr
+}
+
+func TestRedisLockerMutualExclusion(t *testing.T) {
+	l, _ := newTestRedisLocker(t)
+	checkExclusive(t, l)
+}
+
+func TestRedisLockerTryAcquire(t *testing.T) {
+	l, _ := newTestRedisLocker(t)
+	ctx := context.Background()
+
+	lease, err := l.TryAcquire(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryAcquire(ctx, "job", time.Second); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("second TryAcquire err = %v, want ErrNotAcquired", err)
+	}
+	if err := lease.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	next, err := l.TryAcquire(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer next.Release(ctx)
+	if next.Token <= lease.Token {
+		t.Errorf("token %d after %d", next.Token, lease.Token)
+	}
+}
+
+func TestRedisLeaseLostWhenTakenOver(t *testing.T) {
+	l, mr := newTestRedisLocker(t)
+	ctx := context.Background()
+
+	lease, err := l.TryAcquire(ctx, "job", 60*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.Set("app:lock:{job}", "someone-else")
+	select {
+	case <-lease.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost after takeover")
+	}
+	if !lease.Lost() {
+		t.Error("Lost() = false")
+	}
+	if err := lease.Release(ctx); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Release err = %v, want ErrLockLost", err)
+	}
+	if v, _ := mr.Get("app:lock:{job}"); v != "someone-else" {
+		t.Errorf("release touched the new owner's lock: %q", v)
+	}
+}
+
+func newTestPostgresLocker(t *testing.T) (*PostgresLocker, *sql.DB) {
+	t.Helper()
+	db, err := testutil.SQLite(t).DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE lock_fences (key TEXT PRIMARY KEY, token BIGINT NOT NULL, updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPostgresLocker(db), db
+}
+
+// The stand-in lock is process-wide rather than held by a session, so this
+// only checks the locker's own bookkeeping (one lease per key, increasing
+// tokens). TestPostgresLockerMutualExclusion runs against a real server.
+func TestPostgresLockerBookkeeping(t *testing.T) {
+	l, _ := newTestPostgresLocker(t)
+	checkExclusive(t, l)
+}
+
+func newRealPostgresLocker(t *testing.T) *PostgresLocker {
+	t.Helper()
+	db := testutil.Postgres(t)
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS lock_fences (
+		key TEXT PRIMARY KEY,
+		token BIGINT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPostgresLocker(db)
+}
+
+func TestPostgresLockerMutualExclusion(t *testing.T) {
+	checkExclusive(t, newRealPostgresLocker(t))
+}
+
+func TestPostgresLockerReleasedWithSession(t *testing.T) {
+	l := newRealPostgresLocker(t)
+	ctx := context.Background()
+	key := "session-" + uuid.NewString()
+
+	lease, err := l.TryAcquire(ctx, key, 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryAcquire(ctx, key, time.Second); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("second TryAcquire err = %v, want ErrNotAcquired", err)
+	}
+
+	// kill the holding session: Postgres drops its advisory locks
+	_, err = l.db.ExecContext(ctx, `
+		SELECT pg_terminate_backend(pid) FROM pg_locks
+		WHERE locktype = 'advisory' AND objsubid = 1
+		  AND ((classid::bigint << 32) | objid::bigint) = $1`, advisoryID(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lease.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("lease not lost after its session ended")
+	}
+	if !lease.Lost() {
+		t.Error("Lost() = false")
+	}
+
+	next, err := l.TryAcquire(ctx, key, time.Second)
+	if err != nil {
+		t.Fatalf("TryAcquire after the session ended: %v", err)
+	}
+	defer func() { _ = next.Release(ctx) }()
+	if next.Token <= lease.Token {
+		t.Errorf("token %d not above %d", next.Token, lease.Token)
+	}
+}
+
+func TestPostgresLeaseDiscardsConnOnUnlockFailure(t *testing.T) {
+	l, db := newTestPostgresLocker(t)
+	ctx := context.Background()
+
+	lease, err := l.TryAcquire(ctx, "discard", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := db.Stats().OpenConnections
+	failUnlock.Store(true)
+	err = lease.Release(ctx)
+	failUnlock.Store(false)
+	if err == nil {
+		t.Fatal("Release succeeded although unlock failed")
+	}
+	st := db.Stats()
+	if st.OpenConnections != open-1 || st.Idle != 0 {
+		t.Errorf("open %d idle %d after failed unlock, want %d and 0: connection returned to the pool", st.OpenConnections, st.Idle, open-1)
+	}
+
+	advisory.Delete(advisoryID("discard")) // the server would have dropped it with the connection
+	lease, err = l.TryAcquire(ctx, "discard", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lease.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if st := db.Stats(); st.Idle != 1 {
+		t.Errorf("idle %d after a clean release, want 1", st.Idle)
+	}
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process LRU bounded by entry count and total bytes
+// (0 = unbounded). Expired entries are dropped lazily on access or when
+// evicted. Values are copied in and out, so callers may reuse or modify
+// their slices, as with Redis.
+type Memory struct {
+	maxEntries int
+	maxBytes   int64
+
+	mu    sync.Mutex
+	ll    *list.List // front = most recently used
+	items map[string]*list.Element
+	bytes int64
+}
+
+type memEntry struct {
+	key       string
+	val       []byte
+	expiresAt time.Time // zero = never
+}
+
+func NewMemory(maxEntries int, maxBytes int64) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      map[string]*list.Element{},
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	e := el.Value.(*memEntry)
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		m.remove(el)
+		return nil, ErrMiss
+	}
+	m.ll.MoveToFront(el)
+	return bytes.Clone(e.val), nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, val []byte, ttl time.Duration) error {
+	e := &memEntry{key: key, val: bytes.Clone(val)}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		m.remove(el)
+	}
+	m.items[key] = m.ll.PushFront(e)
+	m.bytes += e.size()
+
+	for m.ll.Len() > 1 && ((m.maxEntries > 0 && m.ll.Len() > m.maxEntries) || (m.maxBytes > 0 && m.bytes > m.maxBytes)) {
+		m.remove(m.ll.Back())
+	}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := m.items[k]; ok {
+			m.remove(el)
+		}
+	}
+	return nil
+}
+
+// Purge drops every entry.
+func (m *Memory) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ll.Init()
+	m.items = map[string]*list.Element{}
+	m.bytes = 0
+}
+
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+func (m *Memory) remove(el *list.Element) {
+	e := m.ll.Remove(el).(*memEntry)
+	delete(m.items, e.key)
+	m.bytes -= e.size()
+}
+
+func (e *memEntry) size() int64 { return int64(len(e.key) + len(e.val)) }
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryGetSetDelete(t *testing.T) {
+	m := NewMemory(0, 0)
+	ctx := context.Background()
+
+	if _, err := m.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get on empty cache: err = %v, want ErrMiss", err)
+	}
+	if err := m.Set(ctx, "a", []byte("1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, "a", []byte("2"), 0); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.Get(ctx, "a")
+	if err != nil || string(got) != "2" {
+		t.Fatalf("Get = %q, %v; want 2", got, err)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len = %d after overwrite, want 1", m.Len())
+	}
+	if err := m.Delete(ctx, "a", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after Delete: err = %v, want ErrMiss", err)
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	m := NewMemory(0, 0)
+	ctx := context.Background()
+	_ = m.Set(ctx, "short", []byte("x"), 20*time.Millisecond)
+	_ = m.Set(ctx, "forever", []byte("y"), 0)
+
+	time.Sleep(40 * time.Millisecond)
+	if _, err := m.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expired entry: err = %v, want ErrMiss", err)
+	}
+	if _, err := m.Get(ctx, "forever"); err != nil {
+		t.Errorf("entry without ttl: %v", err)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len = %d, expired entry not dropped", m.Len())
+	}
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewMemory(3, 0)
+	ctx := context.Background()
+	for _, k := range []string{"a", "b", "c"} {
+		_ = m.Set(ctx, k, []byte(k), 0)
+	}
+	_, _ = m.Get(ctx, "a") // a is now the most recent
+	_ = m.Set(ctx, "d", []byte("d"), 0)
+
+	if _, err := m.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("b should have been evicted, err = %v", err)
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if _, err := m.Get(ctx, k); err != nil {
+			t.Errorf("%s evicted: %v", k, err)
+		}
+	}
+}
+
+func TestMemoryMaxBytes(t *testing.T) {
+	// each entry is 1 byte of key + 9 of value
+	m := NewMemory(0, 30)
+	ctx := context.Background()
+	for i := range 5 {
+		_ = m.Set(ctx, fmt.Sprint(i), []byte("123456789"), 0)
+	}
+	if m.Len() != 3 {
+		t.Errorf("Len = %d, want 3 entries within 30 bytes", m.Len())
+	}
+	if _, err := m.Get(ctx, "4"); err != nil {
+		t.Errorf("newest entry evicted: %v", err)
+	}
+
+	// an entry larger than the limit is still kept on its own
+	_ = m.Set(ctx, "big", make([]byte, 100), 0)
+	if m.Len() != 1 {
+		t.Errorf("Len = %d after oversized Set, want 1", m.Len())
+	}
+}
+
+func TestMemoryCopiesValues(t *testing.T) {
+	m := NewMemory(0, 0)
+	ctx := context.Background()
+
+	val := []byte("abc")
+	_ = m.Set(ctx, "k", val, 0)
+	val[0] = 'X'
+
+	got, _ := m.Get(ctx, "k")
+	if string(got) != "abc" {
+		t.Fatalf("Get = %q after caller modified the Set slice", got)
+	}
+	got[0] = 'Y'
+	again, _ := m.Get(ctx, "k")
+	if string(again) != "abc" {
+		t.Errorf("Get = %q after caller modified a Get result", again)
+	}
+}
+
+func TestMemoryPurge(t *testing.T) {
+	m := NewMemory(0, 0)
+	ctx := context.Background()
+	_ = m.Set(ctx, "a", []byte("1"), 0)
+	_ = m.Set(ctx, "b", []byte("2"), 0)
+	m.Purge()
+	if m.Len() != 0 {
+		t.Errorf("Len = %d after Purge", m.Len())
+	}
+	if _, err := m.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after Purge: err = %v", err)
+	}
+}
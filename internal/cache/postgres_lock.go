@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"microseed/internal/db"
+)
+
+// PostgresLocker uses session-level advisory locks, each held on its own
+// connection, for deployments without Redis. The lock lasts as long as the
+// connection, so ttl only sets how often the lease checks it is alive.
+// Fencing tokens come from the lock_fences table. A connection that may
+// still hold a lock is discarded, never returned to the pool.
+type PostgresLocker struct {
+	db *sql.DB
+}
+
+func NewPostgresLocker(db *sql.DB) *PostgresLocker {
+	return &PostgresLocker{db: db}
+}
+
+func (l *PostgresLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	return acquire(ctx, func(ctx context.Context) (*Lease, error) {
+		return l.TryAcquire(ctx, key, ttl)
+	})
+}
+
+func (l *PostgresLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	ttl = leaseTTL(ttl)
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Join(ErrLockUnavailable, err)
+	}
+	lockID := advisoryID(key)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockID).Scan(&ok); err != nil {
+		db.Discard(conn) // the lock may have been taken all the same
+		return nil, err
+	}
+	if !ok {
+		_ = conn.Close()
+		return nil, ErrNotAcquired
+	}
+
+	var token int64
+	err = conn.QueryRowContext(ctx, `
+		INSERT INTO lock_fences (key, token) VALUES ($1, 1)
+		ON CONFLICT (key) DO UPDATE SET token = lock_fences.token + 1, updated_at = NOW()
+		RETURNING token`, key).Scan(&token)
+	if err != nil {
+		db.Discard(conn)
+		return nil, err
+	}
+	return newLease(key, token, ttl, &pgLease{conn: conn, lockID: lockID}), nil
+}
+
+type pgLease struct {
+	conn   *sql.Conn
+	lockID int64
+}
+
+func (p *pgLease) renew(ctx context.Context) (bool, error) {
+	// a dead connection has already dropped the lock
+	if err := p.conn.PingContext(ctx); err != nil {
+		db.Discard(p.conn)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *pgLease) release(ctx context.Context) error {
+	var unlocked bool
+	err := p.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", p.lockID).Scan(&unlocked)
+	if err != nil || !unlocked {
+		db.Discard(p.conn)
+		if err == nil {
+			err = ErrLockLost
+		}
+		return err
+	}
+	return p.conn.Close()
+}
+
+func advisoryID(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("lock:" + key))
+	return int64(h.Sum64())
+}
@@ -2,7 +2,11 @@ package cache
 
 import (
 	"context"
-	"time"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 
 	"microseed/internal/config"
 
@@ -11,28 +15,105 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRedis(cfg *config.Config, log *zap.Logger) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         cfg.RedisAddr,
-		Password:     cfg.RedisPassword,
-		DB:           cfg.RedisDB,
-		ReadTimeout:  200 * time.Millisecond,
-		WriteTimeout: 200 * time.Millisecond,
-		DialTimeout:  500 * time.Millisecond,
-	})
+// NewRedis builds the client for REDIS_MODE. Consumers only see
+// redis.UniversalClient, so they work against a single node, a Sentinel
+// managed master or a Cluster alike.
+func NewRedis(cfg *config.Config, log *zap.Logger) (redis.UniversalClient, error) {
+	tlsCfg, err := redisTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.RedisAddrs,
+		ClientName:       cfg.AppName,
+		DB:               cfg.RedisDB,
+		Username:         cfg.RedisUsername,
+		Password:         cfg.RedisPassword,
+		SentinelPassword: cfg.RedisSentinelPassword,
+		MasterName:       cfg.RedisMasterName,
+		DialTimeout:      cfg.RedisDialTimeout,
+		ReadTimeout:      cfg.RedisReadTimeout,
+		WriteTimeout:     cfg.RedisWriteTimeout,
+		PoolSize:         cfg.RedisPoolSize,
+		MinIdleConns:     cfg.RedisMinIdleConns,
+		TLSConfig:        tlsCfg,
+	}
+
+	var rdb redis.UniversalClient
+	switch cfg.RedisMode {
+	case "", "single":
+		opts.Addrs = []string{cfg.RedisAddr}
+		rdb = redis.NewClient(opts.Simple())
+	case "sentinel":
+		if cfg.RedisMasterName == "" {
+			return nil, errors.New("redis: REDIS_MASTER_NAME is required in sentinel mode")
+		}
+		rdb = redis.NewFailoverClient(opts.Failover())
+	case "cluster":
+		if cfg.RedisDB != 0 {
+			log.Warn("REDIS_DB is ignored in cluster mode", zap.Int("db", cfg.RedisDB))
+		}
+		rdb = redis.NewClusterClient(opts.Cluster())
+	default:
+		return nil, fmt.Errorf("redis: unknown mode %q", cfg.RedisMode)
+	}
+	if err := instrumentRedis(rdb, cfg.RedisSlowThreshold, log); err != nil {
+		_ = rdb.Close()
+		return nil, err
+	}
 	return rdb, nil
 }
 
-func RegisterHooks(lc fx.Lifecycle, rdb *redis.Client, log *zap.Logger) {
+// redisTLS returns nil unless REDIS_TLS is on or a TLS file is given.
+func redisTLS(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.RedisTLS && cfg.RedisTLSCAFile == "" && cfg.RedisTLSCertFile == "" {
+		return nil, nil
+	}
+	tc := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.RedisTLSServerName,
+	}
+	if cfg.RedisTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.RedisTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: read CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis: no certificates in %s", cfg.RedisTLSCAFile)
+		}
+		tc.RootCAs = pool
+	}
+	if cfg.RedisTLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.RedisTLSCertFile, cfg.RedisTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: load client cert: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+	return tc, nil
+}
+
+// RegisterHooks pings Redis on start. With REDIS_REQUIRED=false a failed
+// ping only logs a warning; the availability loop keeps probing and
+// reports when Redis comes back.
+func RegisterHooks(lc fx.Lifecycle, rdb redis.UniversalClient, avail *Availability, cfg *config.Config, log *zap.Logger) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			_, err := rdb.Ping(ctx).Result()
+			err := avail.Check(ctx)
+			if err != nil && cfg.RedisRequired {
+				return err
+			}
 			if err == nil {
-				log.Info("redis connected")
+				log.Info("redis connected", zap.String("mode", cfg.RedisMode))
+			} else {
+				log.Warn("redis unavailable, starting in degraded mode", zap.Error(err))
 			}
-			return err
+			avail.Start()
+			return nil
 		},
 		OnStop: func(ctx context.Context) error {
+			avail.Stop()
 			log.Info("closing redis")
 			return rdb.Close()
 		},
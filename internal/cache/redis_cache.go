@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores entries under <prefix><key>. While Redis is known to be
+// unavailable reads miss and writes are skipped instead of timing out.
+type RedisCache struct {
+	rdb    redis.UniversalClient
+	avail  *Availability
+	prefix string
+}
+
+func NewRedisCache(rdb redis.UniversalClient, avail *Availability, prefix string) *RedisCache {
+	return &RedisCache{rdb: rdb, avail: avail, prefix: prefix}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if !c.avail.Available() {
+		return nil, ErrMiss
+	}
+	b, err := c.rdb.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return b, err
+}
+
+// getWithTTL also returns the entry's remaining ttl, 0 when it has none.
+func (c *RedisCache) getWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	if !c.avail.Available() {
+		return nil, 0, ErrMiss
+	}
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err := c.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		get = p.Get(ctx, c.prefix+key)
+		pttl = p.PTTL(ctx, c.prefix+key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, ErrMiss
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	b, _ := get.Bytes()
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = 0 // -1: no expiry
+	}
+	return b, ttl, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	if !c.avail.Available() {
+		return nil
+	}
+	return c.rdb.Set(ctx, c.prefix+key, val, ttl).Err()
+}
+
+// Delete is skipped while Redis is unavailable, like Set: entries written
+// before the outage still expire with their ttl.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 || !c.avail.Available() {
+		return nil
+	}
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = c.prefix + k
+	}
+	return c.rdb.Del(ctx, full...).Err()
+}
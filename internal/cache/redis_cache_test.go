@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"microseed/internal/testutil"
+
+	"go.uber.org/zap"
+)
+
+func TestRedisCacheSkipsWhileUnavailable(t *testing.T) {
+	mr, rdb := testutil.Redis(t)
+	avail := NewAvailability(rdb, zap.NewNop()) // not probed yet: unavailable
+	c := NewRedisCache(rdb, avail, "app:cache:")
+	ctx := context.Background()
+	_ = mr.Set("app:cache:k", "v")
+
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "other", []byte("x"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("app:cache:k") || mr.Exists("app:cache:other") {
+		t.Fatal("cache reached Redis while marked unavailable")
+	}
+
+	if err := avail.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("app:cache:k") {
+		t.Error("Delete skipped while available")
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"microseed/pkg/id"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Returns the new fencing token, or 0 when the lock is held.
+var lockAcquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+var lockRenewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var lockReleaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker stores <prefix>{key} = owner with a TTL and keeps a fencing
+// counter in <prefix>{key}:fence. The hash tag keeps both in one cluster
+// slot. It fails closed: no leases while Redis is unavailable.
+type RedisLocker struct {
+	rdb    redis.UniversalClient
+	avail  *Availability
+	prefix string
+}
+
+func NewRedisLocker(rdb redis.UniversalClient, avail *Availability, prefix string) *RedisLocker {
+	return &RedisLocker{rdb: rdb, avail: avail, prefix: prefix}
+}
+
+func (l *RedisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	return acquire(ctx, func(ctx context.Context) (*Lease, error) {
+		return l.TryAcquire(ctx, key, ttl)
+	})
+}
+
+func (l *RedisLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lease, error) {
+	if !l.avail.Available() {
+		return nil, ErrLockUnavailable
+	}
+	ttl = leaseTTL(ttl)
+	k := l.prefix + "{" + key + "}"
+	owner := id.New()
+	token, err := lockAcquireScript.Run(ctx, l.rdb, []string{k, k + ":fence"}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+	return newLease(key, token, ttl, &redisLease{rdb: l.rdb, key: k, owner: owner, ttl: ttl}), nil
+}
+
+type redisLease struct {
+	rdb   redis.UniversalClient
+	key   string
+	owner string
+	ttl   time.Duration
+}
+
+func (r *redisLease) renew(ctx context.Context) (bool, error) {
+	n, err := lockRenewScript.Run(ctx, r.rdb, []string{r.key}, r.owner, r.ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+func (r *redisLease) release(ctx context.Context) error {
+	return lockReleaseScript.Run(ctx, r.rdb, []string{r.key}, r.owner).Err()
+}
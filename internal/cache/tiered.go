@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"microseed/pkg/backoff"
+	"microseed/pkg/id"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const l1Jitter = 0.2 // L1 TTLs are shortened by up to 20% so hot keys don't expire together
+
+// Tiered keeps an in-process L1 (Memory) in front of Redis (L2). Writes and
+// deletes are broadcast on a pub/sub channel so every replica evicts its L1
+// copy; fills are not, since they store what the source already holds.
+// Whenever the subscription is (re)established L1 is purged, since
+// invalidations may have been missed while disconnected.
+type Tiered struct {
+	l1       *Memory
+	l2       Cache
+	rdb      redis.UniversalClient
+	channel  string
+	l1TTL    time.Duration
+	instance string
+	log      *zap.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+func NewTiered(l1 *Memory, l2 Cache, rdb redis.UniversalClient, channel string, l1TTL time.Duration, log *zap.Logger) *Tiered {
+	return &Tiered{
+		l1:       l1,
+		l2:       l2,
+		rdb:      rdb,
+		channel:  channel,
+		l1TTL:    l1TTL,
+		instance: id.New(),
+		log:      log.Named("cache"),
+	}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if b, err := t.l1.Get(ctx, key); err == nil {
+		return b, nil
+	}
+	var b []byte
+	var ttl time.Duration
+	var err error
+	if rc, ok := t.l2.(*RedisCache); ok {
+		b, ttl, err = rc.getWithTTL(ctx, key)
+	} else {
+		b, err = t.l2.Get(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// L1 must not outlive the L2 entry (short-lived negative entries)
+	t.setL1(ctx, key, b, ttl)
+	return b, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	if err := t.Fill(ctx, key, val, ttl); err != nil {
+		return err
+	}
+	t.broadcast(ctx, key)
+	return nil
+}
+
+// Fill stores a read-through value without broadcasting an invalidation.
+func (t *Tiered) Fill(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	if err := t.l2.Set(ctx, key, val, ttl); err != nil {
+		return err
+	}
+	t.setL1(ctx, key, val, ttl)
+	return nil
+}
+
+func (t *Tiered) setL1(ctx context.Context, key string, val []byte, ttl time.Duration) {
+	l1TTL := t.l1TTL
+	if ttl > 0 && ttl < l1TTL {
+		l1TTL = ttl
+	}
+	_ = t.l1.Set(ctx, key, val, t.jittered(l1TTL))
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	_ = t.l1.Delete(ctx, keys...)
+	if err := t.l2.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	t.broadcast(ctx, keys...)
+	return nil
+}
+
+func (t *Tiered) jittered(d time.Duration) time.Duration {
+	return d - time.Duration(rand.Float64()*l1Jitter*float64(d))
+}
+
+func (t *Tiered) broadcast(ctx context.Context, keys ...string) {
+	msg, _ := json.Marshal(invalidation{Origin: t.instance, Keys: keys})
+	if err := t.rdb.Publish(ctx, t.channel, msg).Err(); err != nil {
+		t.log.Debug("invalidation publish failed", zap.Error(err))
+	}
+}
+
+func (t *Tiered) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.subscribeLoop(ctx)
+	}()
+}
+
+func (t *Tiered) Stop() {
+	if t.cancel == nil {
+		return
+	}
+	t.cancel()
+	t.wg.Wait()
+}
+
+func (t *Tiered) subscribeLoop(ctx context.Context) {
+	attempt := 0
+	for ctx.Err() == nil {
+		err := t.listen(ctx, func() { attempt = 0 })
+		if ctx.Err() != nil {
+			return
+		}
+		attempt++
+		t.log.Warn("invalidation subscription lost", zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.Exponential(attempt, 200*time.Millisecond, 30*time.Second)):
+		}
+	}
+}
+
+// listen subscribes and applies invalidations until the connection breaks.
+func (t *Tiered) listen(ctx context.Context, subscribed func()) error {
+	ps := t.rdb.Subscribe(ctx, t.channel)
+	defer ps.Close()
+	// a blocked receive does not watch ctx; closing the PubSub unblocks it
+	stop := context.AfterFunc(ctx, func() { _ = ps.Close() })
+	defer stop()
+	if _, err := ps.Receive(ctx); err != nil {
+		return err
+	}
+	t.l1.Purge()
+	subscribed()
+	t.log.Info("invalidation subscription active", zap.String("channel", t.channel))
+
+	for {
+		msg, err := ps.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		var inv invalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Origin == t.instance {
+			continue
+		}
+		_ = t.l1.Delete(ctx, inv.Keys...)
+	}
+}
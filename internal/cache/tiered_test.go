@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"microseed/internal/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestTiered(t *testing.T) (*Tiered, *miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	mr, rdb := testutil.Redis(t)
+	avail := NewAvailability(rdb, zap.NewNop())
+	if err := avail.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tc := NewTiered(NewMemory(0, 0), NewRedisCache(rdb, avail, "app:cache:"), rdb, "app:cache:invalidate", time.Minute, zap.NewNop())
+	return tc, mr, rdb
+}
+
+func TestTieredL1FollowsL2TTL(t *testing.T) {
+	tc, mr, _ := newTestTiered(t)
+	ctx := context.Background()
+	_ = mr.Set("app:cache:neg", "missing")
+	mr.SetTTL("app:cache:neg", 50*time.Millisecond)
+
+	if b, err := tc.Get(ctx, "neg"); err != nil || string(b) != "missing" {
+		t.Fatalf("Get = %q, %v", b, err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	mr.FastForward(80 * time.Millisecond)
+	if _, err := tc.Get(ctx, "neg"); !IsMiss(err) {
+		t.Errorf("Get after L2 expiry err = %v, want a miss", err)
+	}
+}
+
+func TestTieredBroadcastsWritesOnly(t *testing.T) {
+	tc, _, rdb := newTestTiered(t)
+	ctx := context.Background()
+	ps := rdb.Subscribe(ctx, "app:cache:invalidate")
+	defer ps.Close()
+	if _, err := ps.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	users := NewTyped[string](tc, JSON, "user:")
+	if _, err := users.GetOrLoad(ctx, "1", time.Minute, func(context.Context) (string, error) { return "a", nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Set(ctx, "2", "b", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	msg, err := ps.ReceiveMessage(rctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Payload != `{"origin":"`+tc.instance+`","keys":["user:2"]}` {
+		t.Errorf("first invalidation = %s, want only the Set of user:2", msg.Payload)
+	}
+}
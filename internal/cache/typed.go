@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Typed stores values of type T in a Cache through a Codec, under
+// <prefix><key>.
+type Typed[T any] struct {
+	c      Cache
+	codec  Codec
+	prefix string
+}
+
+func NewTyped[T any](c Cache, codec Codec, prefix string) *Typed[T] {
+	return &Typed[T]{c: c, codec: codec, prefix: prefix}
+}
+
+// Get returns ErrMiss when the key is absent.
+func (t *Typed[T]) Get(ctx context.Context, key string) (T, error) {
+	var v T
+	b, err := t.c.Get(ctx, t.prefix+key)
+	if err != nil {
+		return v, err
+	}
+	err = t.codec.Unmarshal(b, &v)
+	return v, err
+}
+
+func (t *Typed[T]) Set(ctx context.Context, key string, v T, ttl time.Duration) error {
+	b, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.c.Set(ctx, t.prefix+key, b, ttl)
+}
+
+// Fill caches a value just loaded from the source of truth. Unlike Set it
+// is not a write: a Tiered cache skips the invalidation broadcast.
+func (t *Typed[T]) Fill(ctx context.Context, key string, v T, ttl time.Duration) error {
+	b, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if f, ok := t.c.(Filler); ok {
+		return f.Fill(ctx, t.prefix+key, b, ttl)
+	}
+	return t.c.Set(ctx, t.prefix+key, b, ttl)
+}
+
+func (t *Typed[T]) Delete(ctx context.Context, keys ...string) error {
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = t.prefix + k
+	}
+	return t.c.Delete(ctx, full...)
+}
+
+// GetOrLoad returns the cached value or calls load and caches its result.
+// Cache errors are treated as a miss; load errors are returned uncached.
+func (t *Typed[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	v, err := t.Get(ctx, key)
+	if err == nil {
+		return v, nil
+	}
+	v, err = load(ctx)
+	if err != nil {
+		return v, err
+	}
+	_ = t.Fill(ctx, key, v, ttl)
+	return v, nil
+}
+
+// IsMiss reports whether err means the key was not cached.
+func IsMiss(err error) bool { return errors.Is(err, ErrMiss) }
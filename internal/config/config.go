@@ -1,16 +1,22 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppName         string
-	HTTPAddr        string
-	GracefulTimeout time.Duration
+	AppName            string
+	HTTPAddr           string
+	GracefulTimeout    time.Duration
+	ShutdownDrainDelay time.Duration // readiness fails this long before the HTTP server stops
+	AdminToken         string        // bearer token for /admin endpoints; empty = disabled
+	TrustedProxies     []string      // IPs / CIDRs whose X-Forwarded-For is believed; empty = none
 
 	// Postgres
 	DBDSN             string
@@ -20,9 +26,92 @@ type Config struct {
 	DBConnMaxIdleTime time.Duration
 
 	// Redis
-	RedisAddr     string
-	RedisPassword string
-	RedisDB       int
+	RedisMode             string   // "single" | "sentinel" | "cluster"
+	RedisAddr             string   // single
+	RedisAddrs            []string // sentinel / cluster seeds; defaults to RedisAddr
+	RedisMasterName       string   // sentinel
+	RedisUsername         string   // ACL user
+	RedisPassword         string
+	RedisSentinelPassword string
+	RedisDB               int  // ignored in cluster mode
+	RedisRequired         bool // false = boot and run degraded without Redis
+	RedisTLS              bool
+	RedisTLSCAFile        string
+	RedisTLSCertFile      string
+	RedisTLSKeyFile       string
+	RedisTLSServerName    string
+	RedisDialTimeout      time.Duration
+	RedisReadTimeout      time.Duration
+	RedisWriteTimeout     time.Duration
+	RedisPoolSize         int // 0 = go-redis default (10 per CPU)
+	RedisMinIdleConns     int
+	RedisSlowThreshold    time.Duration // log slower commands; 0 = off
+
+	// Cache
+	CacheDriver     string        // "redis" | "memory" | "tiered"
+	CacheCodec      string        // "json" | "msgpack"
+	CacheMaxEntries int           // memory driver (L1 for tiered)
+	CacheMaxBytes   int64         // memory driver (L1 for tiered)
+	CacheL1TTL      time.Duration // tiered driver
+	LockDriver      string        // "redis" | "postgres"
+
+	UserCacheEnabled     bool // decorate user.Service with the read-through cache
+	UserCacheTTL         time.Duration
+	UserCacheNegativeTTL time.Duration // not-found IDs; 0 = disabled
+
+	// Jobs
+	JobsBackend  string         // "redis" | "memory"
+	JobsQueues   map[string]int // queue -> concurrency
+	JobsMaxRetry int
+
+	// Scheduler
+	SchedulerEnabled bool
+	SchedulerLeader  string // "redis" | "postgres"
+	SchedulerLockTTL time.Duration
+
+	// Outbox
+	OutboxRelayEnabled bool
+	OutboxPublisher    string // "local" | "redis" | "nats" | "webhook"
+	OutboxWebhookURL   string
+	OutboxPollInterval time.Duration
+	OutboxBatchSize    int
+	OutboxRetention    time.Duration
+
+	// Redis Streams
+	StreamGroup         string // consumer group; default APP_NAME
+	StreamMaxLen        int64  // approximate MAXLEN on XADD
+	StreamConcurrency   int    // in-flight messages per handler
+	StreamMaxDeliveries int64  // poison threshold before dead-lettering
+	StreamClaimMinIdle  time.Duration
+	StreamClaimInterval time.Duration
+
+	// Messaging
+	MessagingDriver string // "none" | "nats"
+	NATSURL         string
+	NATSStream      string   // JetStream stream; default APP_NAME uppercased
+	NATSSubjects    []string // subjects bound to the stream; default "<APP_NAME>.>"
+	NATSDurable     string   // durable consumer prefix; default APP_NAME
+	NATSMaxDeliver  int
+	NATSAckWait     time.Duration
+
+	// Outgoing webhooks
+	WebhookQueue        string
+	WebhookTimeout      time.Duration
+	WebhookMaxRetry     int  // attempts per delivery after the first
+	WebhookMaxFailures  int  // consecutive failures before a subscription is disabled
+	WebhookAllowPrivate bool // allow loopback / private targets (development only)
+
+	// Rate limiting
+	RateLimitBackend      string // "redis" | "memory"
+	RateLimitAlgorithm    string // "token_bucket" | "sliding_window"
+	RateLimitKey          string // "auto" | "ip" | "api_key" | "user"
+	RateLimitAPIKeyHeader string
+	RateLimitRules        []RateLimitRule // empty = disabled
+
+	// Idempotency-Key
+	IdempotencyStore   string        // "redis" | "postgres"
+	IdempotencyTTL     time.Duration // how long responses are replayed
+	IdempotencyLockTTL time.Duration // how long an in-progress request blocks retries
 
 	// Logger
 	LogLevel          string
@@ -34,6 +123,22 @@ type Config struct {
 	LogFileCompress   bool
 	LogStackAt        string
 
+	// Access log
+	AccessLogSkipPaths     []string // exact paths, never logged
+	AccessLogSampleRate    float64  // fraction of < 400 responses logged
+	AccessLogBodyMaxBytes  int      // body capture at debug level (0 = off)
+	AccessLogRedactHeaders []string
+	AccessLogRedactFields  []string // JSON / form / query keys
+
+	// Traffic recording (microseed replay)
+	TrafficRecordEnabled      bool
+	TrafficRecordFile         string
+	TrafficRecordSampleRate   float64
+	TrafficRecordMaxBodyBytes int
+	TrafficRecordRedactFields []string // headers use AccessLogRedactHeaders
+	TrafficRecordMaxSizeMB    int
+	TrafficRecordMaxBackups   int
+
 	// OTel
 	OTLPEndpoint string
 	OTelService  string
@@ -50,6 +155,9 @@ func New() (*Config, error) {
 	v.SetDefault("APP_NAME", "microseed")
 	v.SetDefault("HTTP_ADDR", ":8080")
 	v.SetDefault("GRACEFUL_TIMEOUT", "10s")
+	v.SetDefault("SHUTDOWN_DRAIN_DELAY", "0s")
+	v.SetDefault("ADMIN_TOKEN", "")
+	v.SetDefault("TRUSTED_PROXIES", "")
 
 	v.SetDefault("DB_DSN", "host=localhost user=postgres password=postgres dbname=microseed port=5432 sslmode=disable TimeZone=Asia/Jakarta")
 	v.SetDefault("DB_MAX_OPEN", 30)
@@ -57,9 +165,82 @@ func New() (*Config, error) {
 	v.SetDefault("DB_CONN_MAX_LIFETIME", "60m")
 	v.SetDefault("DB_CONN_MAX_IDLE_TIME", "10m")
 
+	v.SetDefault("REDIS_MODE", "single")
 	v.SetDefault("REDIS_ADDR", "localhost:6379")
+	v.SetDefault("REDIS_ADDRS", "")
+	v.SetDefault("REDIS_MASTER_NAME", "")
+	v.SetDefault("REDIS_USERNAME", "")
 	v.SetDefault("REDIS_PASSWORD", "")
+	v.SetDefault("REDIS_SENTINEL_PASSWORD", "")
 	v.SetDefault("REDIS_DB", 0)
+	v.SetDefault("REDIS_REQUIRED", true)
+	v.SetDefault("REDIS_TLS", false)
+	v.SetDefault("REDIS_TLS_CA_FILE", "")
+	v.SetDefault("REDIS_TLS_CERT_FILE", "")
+	v.SetDefault("REDIS_TLS_KEY_FILE", "")
+	v.SetDefault("REDIS_TLS_SERVER_NAME", "")
+	v.SetDefault("REDIS_DIAL_TIMEOUT", "500ms")
+	v.SetDefault("REDIS_READ_TIMEOUT", "200ms")
+	v.SetDefault("REDIS_WRITE_TIMEOUT", "200ms")
+	v.SetDefault("REDIS_POOL_SIZE", 0)
+	v.SetDefault("REDIS_MIN_IDLE_CONNS", 0)
+	v.SetDefault("REDIS_SLOW_THRESHOLD", "50ms")
+
+	v.SetDefault("CACHE_DRIVER", "redis")
+	v.SetDefault("CACHE_CODEC", "json")
+	v.SetDefault("CACHE_MAX_ENTRIES", 10000)
+	v.SetDefault("CACHE_MAX_BYTES", 64<<20)
+	v.SetDefault("CACHE_L1_TTL", "30s")
+	v.SetDefault("LOCK_DRIVER", "redis")
+	v.SetDefault("USER_CACHE_ENABLED", false)
+	v.SetDefault("USER_CACHE_TTL", "5m")
+	v.SetDefault("USER_CACHE_NEGATIVE_TTL", "30s")
+
+	v.SetDefault("JOBS_BACKEND", "redis")
+	v.SetDefault("JOBS_QUEUES", "default:10")
+	v.SetDefault("JOBS_MAX_RETRY", 10)
+
+	v.SetDefault("SCHEDULER_ENABLED", true)
+	v.SetDefault("SCHEDULER_LEADER", "redis")
+	v.SetDefault("SCHEDULER_LOCK_TTL", "30s")
+
+	v.SetDefault("OUTBOX_RELAY_ENABLED", true)
+	v.SetDefault("OUTBOX_PUBLISHER", "local")
+	v.SetDefault("OUTBOX_WEBHOOK_URL", "")
+	v.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	v.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	v.SetDefault("OUTBOX_RETENTION", "168h")
+
+	v.SetDefault("STREAM_GROUP", "")
+	v.SetDefault("STREAM_MAXLEN", 100000)
+	v.SetDefault("STREAM_CONCURRENCY", 10)
+	v.SetDefault("STREAM_MAX_DELIVERIES", 5)
+	v.SetDefault("STREAM_CLAIM_MIN_IDLE", "1m")
+	v.SetDefault("STREAM_CLAIM_INTERVAL", "30s")
+
+	v.SetDefault("MESSAGING_DRIVER", "none")
+	v.SetDefault("NATS_URL", "nats://localhost:4222")
+	v.SetDefault("NATS_STREAM", "")
+	v.SetDefault("NATS_SUBJECTS", "")
+	v.SetDefault("NATS_DURABLE", "")
+	v.SetDefault("NATS_MAX_DELIVER", 5)
+	v.SetDefault("NATS_ACK_WAIT", "30s")
+
+	v.SetDefault("WEBHOOK_QUEUE", "default")
+	v.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	v.SetDefault("WEBHOOK_MAX_RETRY", 8)
+	v.SetDefault("WEBHOOK_MAX_FAILURES", 20)
+	v.SetDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
+
+	v.SetDefault("RATE_LIMIT_BACKEND", "redis")
+	v.SetDefault("RATE_LIMIT_ALGORITHM", "token_bucket")
+	v.SetDefault("RATE_LIMIT_KEY", "auto")
+	v.SetDefault("RATE_LIMIT_API_KEY_HEADER", "X-API-Key")
+	v.SetDefault("RATE_LIMIT_RULES", "/v1=100/1m")
+
+	v.SetDefault("IDEMPOTENCY_STORE", "redis")
+	v.SetDefault("IDEMPOTENCY_TTL", "24h")
+	v.SetDefault("IDEMPOTENCY_LOCK_TTL", "1m")
 
 	// --- Logging defaults ---
 	v.SetDefault("LOG_LEVEL", "info")
@@ -70,6 +251,18 @@ func New() (*Config, error) {
 	v.SetDefault("LOG_FILE_MAX_AGE_DAYS", 30)
 	v.SetDefault("LOG_FILE_COMPRESS", true)
 	v.SetDefault("LOG_STACK_AT", "error")
+	v.SetDefault("ACCESS_LOG_SKIP_PATHS", "/healthz,/readyz,/startupz")
+	v.SetDefault("ACCESS_LOG_SAMPLE_RATE", 1.0)
+	v.SetDefault("ACCESS_LOG_BODY_MAX_BYTES", 4096)
+	v.SetDefault("ACCESS_LOG_REDACT_HEADERS", "Authorization,Cookie,X-API-Key")
+	v.SetDefault("ACCESS_LOG_REDACT_FIELDS", "password,email,token,secret")
+	v.SetDefault("TRAFFIC_RECORD_ENABLED", false)
+	v.SetDefault("TRAFFIC_RECORD_FILE", "logs/traffic.jsonl")
+	v.SetDefault("TRAFFIC_RECORD_SAMPLE_RATE", 1.0)
+	v.SetDefault("TRAFFIC_RECORD_MAX_BODY_BYTES", 65536)
+	v.SetDefault("TRAFFIC_RECORD_REDACT_FIELDS", "password,email,token,secret")
+	v.SetDefault("TRAFFIC_RECORD_MAX_SIZE_MB", 100)
+	v.SetDefault("TRAFFIC_RECORD_MAX_BACKUPS", 5)
 
 	v.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "")
 	v.SetDefault("OTEL_SERVICE_NAME", "microseed-api")
@@ -78,32 +271,147 @@ func New() (*Config, error) {
 	_ = v.ReadInConfig()
 
 	timeout, _ := time.ParseDuration(v.GetString("GRACEFUL_TIMEOUT"))
+	drainDelay, _ := time.ParseDuration(v.GetString("SHUTDOWN_DRAIN_DELAY"))
 	lifetime, _ := time.ParseDuration(v.GetString("DB_CONN_MAX_LIFETIME"))
 	idleTime, _ := time.ParseDuration(v.GetString("DB_CONN_MAX_IDLE_TIME"))
+	redisDial, _ := time.ParseDuration(v.GetString("REDIS_DIAL_TIMEOUT"))
+	redisRead, _ := time.ParseDuration(v.GetString("REDIS_READ_TIMEOUT"))
+	redisWrite, _ := time.ParseDuration(v.GetString("REDIS_WRITE_TIMEOUT"))
+	redisSlow, _ := time.ParseDuration(v.GetString("REDIS_SLOW_THRESHOLD"))
+	lockTTL, _ := time.ParseDuration(v.GetString("SCHEDULER_LOCK_TTL"))
+	outboxPoll, _ := time.ParseDuration(v.GetString("OUTBOX_POLL_INTERVAL"))
+	outboxRetention, _ := time.ParseDuration(v.GetString("OUTBOX_RETENTION"))
+	claimMinIdle, _ := time.ParseDuration(v.GetString("STREAM_CLAIM_MIN_IDLE"))
+	claimInterval, _ := time.ParseDuration(v.GetString("STREAM_CLAIM_INTERVAL"))
+	ackWait, _ := time.ParseDuration(v.GetString("NATS_ACK_WAIT"))
+	webhookTimeout, _ := time.ParseDuration(v.GetString("WEBHOOK_TIMEOUT"))
+	idemTTL, _ := time.ParseDuration(v.GetString("IDEMPOTENCY_TTL"))
+	idemLockTTL, _ := time.ParseDuration(v.GetString("IDEMPOTENCY_LOCK_TTL"))
+	l1TTL, _ := time.ParseDuration(v.GetString("CACHE_L1_TTL"))
+	userCacheTTL, _ := time.ParseDuration(v.GetString("USER_CACHE_TTL"))
+	userCacheNegTTL, _ := time.ParseDuration(v.GetString("USER_CACHE_NEGATIVE_TTL"))
+	rateLimitRules, err := parseRateLimitRules(v.GetString("RATE_LIMIT_RULES"))
+	if err != nil {
+		return nil, err
+	}
 
 	cfg := &Config{
-		AppName:           v.GetString("APP_NAME"),
-		HTTPAddr:          v.GetString("HTTP_ADDR"),
-		GracefulTimeout:   defDur(timeout, 10*time.Second),
-		DBDSN:             v.GetString("DB_DSN"),
-		DBMaxOpen:         v.GetInt("DB_MAX_OPEN"),
-		DBMaxIdle:         v.GetInt("DB_MAX_IDLE"),
-		DBConnMaxLifetime: defDur(lifetime, 60*time.Minute),
-		DBConnMaxIdleTime: defDur(idleTime, 10*time.Minute),
-		RedisAddr:         v.GetString("REDIS_ADDR"),
-		RedisPassword:     v.GetString("REDIS_PASSWORD"),
-		RedisDB:           v.GetInt("REDIS_DB"),
-		OTLPEndpoint:      v.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"),
-		OTelService:       v.GetString("OTEL_SERVICE_NAME"),
-		OTelEnv:           v.GetString("OTEL_ENV"),
-		LogLevel:          v.GetString("LOG_LEVEL"),
-		LogConsole:        v.GetBool("LOG_CONSOLE"),
-		LogFilePath:       v.GetString("LOG_FILE_PATH"),
-		LogFileMaxSizeMB:  v.GetInt("LOG_FILE_MAX_SIZE_MB"),
-		LogFileMaxBackups: v.GetInt("LOG_FILE_MAX_BACKUPS"),
-		LogFileMaxAgeDays: v.GetInt("LOG_FILE_MAX_AGE_DAYS"),
-		LogFileCompress:   v.GetBool("LOG_FILE_COMPRESS"),
-		LogStackAt:        v.GetString("LOG_STACK_AT"),
+		AppName:                   v.GetString("APP_NAME"),
+		HTTPAddr:                  v.GetString("HTTP_ADDR"),
+		GracefulTimeout:           defDur(timeout, 10*time.Second),
+		ShutdownDrainDelay:        drainDelay,
+		AdminToken:                v.GetString("ADMIN_TOKEN"),
+		TrustedProxies:            splitList(v.GetString("TRUSTED_PROXIES")),
+		DBDSN:                     v.GetString("DB_DSN"),
+		DBMaxOpen:                 v.GetInt("DB_MAX_OPEN"),
+		DBMaxIdle:                 v.GetInt("DB_MAX_IDLE"),
+		DBConnMaxLifetime:         defDur(lifetime, 60*time.Minute),
+		DBConnMaxIdleTime:         defDur(idleTime, 10*time.Minute),
+		RedisMode:                 v.GetString("REDIS_MODE"),
+		RedisAddr:                 v.GetString("REDIS_ADDR"),
+		RedisAddrs:                splitList(v.GetString("REDIS_ADDRS")),
+		RedisMasterName:           v.GetString("REDIS_MASTER_NAME"),
+		RedisUsername:             v.GetString("REDIS_USERNAME"),
+		RedisPassword:             v.GetString("REDIS_PASSWORD"),
+		RedisSentinelPassword:     v.GetString("REDIS_SENTINEL_PASSWORD"),
+		RedisDB:                   v.GetInt("REDIS_DB"),
+		RedisRequired:             v.GetBool("REDIS_REQUIRED"),
+		RedisTLS:                  v.GetBool("REDIS_TLS"),
+		RedisTLSCAFile:            v.GetString("REDIS_TLS_CA_FILE"),
+		RedisTLSCertFile:          v.GetString("REDIS_TLS_CERT_FILE"),
+		RedisTLSKeyFile:           v.GetString("REDIS_TLS_KEY_FILE"),
+		RedisTLSServerName:        v.GetString("REDIS_TLS_SERVER_NAME"),
+		RedisDialTimeout:          defDur(redisDial, 500*time.Millisecond),
+		RedisReadTimeout:          defDur(redisRead, 200*time.Millisecond),
+		RedisWriteTimeout:         defDur(redisWrite, 200*time.Millisecond),
+		RedisPoolSize:             v.GetInt("REDIS_POOL_SIZE"),
+		RedisMinIdleConns:         v.GetInt("REDIS_MIN_IDLE_CONNS"),
+		RedisSlowThreshold:        redisSlow,
+		CacheDriver:               v.GetString("CACHE_DRIVER"),
+		CacheCodec:                v.GetString("CACHE_CODEC"),
+		CacheMaxEntries:           v.GetInt("CACHE_MAX_ENTRIES"),
+		CacheMaxBytes:             v.GetInt64("CACHE_MAX_BYTES"),
+		CacheL1TTL:                defDur(l1TTL, 30*time.Second),
+		LockDriver:                v.GetString("LOCK_DRIVER"),
+		UserCacheEnabled:          v.GetBool("USER_CACHE_ENABLED"),
+		UserCacheTTL:              defDur(userCacheTTL, 5*time.Minute),
+		UserCacheNegativeTTL:      userCacheNegTTL,
+		JobsBackend:               v.GetString("JOBS_BACKEND"),
+		JobsQueues:                parseQueues(v.GetString("JOBS_QUEUES")),
+		JobsMaxRetry:              v.GetInt("JOBS_MAX_RETRY"),
+		SchedulerEnabled:          v.GetBool("SCHEDULER_ENABLED"),
+		SchedulerLeader:           v.GetString("SCHEDULER_LEADER"),
+		SchedulerLockTTL:          defDur(lockTTL, 30*time.Second),
+		OutboxRelayEnabled:        v.GetBool("OUTBOX_RELAY_ENABLED"),
+		OutboxPublisher:           v.GetString("OUTBOX_PUBLISHER"),
+		OutboxWebhookURL:          v.GetString("OUTBOX_WEBHOOK_URL"),
+		OutboxPollInterval:        defDur(outboxPoll, time.Second),
+		OutboxBatchSize:           v.GetInt("OUTBOX_BATCH_SIZE"),
+		OutboxRetention:           defDur(outboxRetention, 7*24*time.Hour),
+		StreamGroup:               v.GetString("STREAM_GROUP"),
+		StreamMaxLen:              v.GetInt64("STREAM_MAXLEN"),
+		StreamConcurrency:         v.GetInt("STREAM_CONCURRENCY"),
+		StreamMaxDeliveries:       v.GetInt64("STREAM_MAX_DELIVERIES"),
+		StreamClaimMinIdle:        defDur(claimMinIdle, time.Minute),
+		StreamClaimInterval:       defDur(claimInterval, 30*time.Second),
+		MessagingDriver:           v.GetString("MESSAGING_DRIVER"),
+		NATSURL:                   v.GetString("NATS_URL"),
+		NATSStream:                v.GetString("NATS_STREAM"),
+		NATSSubjects:              splitList(v.GetString("NATS_SUBJECTS")),
+		NATSDurable:               v.GetString("NATS_DURABLE"),
+		NATSMaxDeliver:            v.GetInt("NATS_MAX_DELIVER"),
+		NATSAckWait:               defDur(ackWait, 30*time.Second),
+		WebhookQueue:              v.GetString("WEBHOOK_QUEUE"),
+		WebhookTimeout:            defDur(webhookTimeout, 10*time.Second),
+		WebhookMaxRetry:           v.GetInt("WEBHOOK_MAX_RETRY"),
+		WebhookMaxFailures:        v.GetInt("WEBHOOK_MAX_FAILURES"),
+		WebhookAllowPrivate:       v.GetBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS"),
+		RateLimitBackend:          v.GetString("RATE_LIMIT_BACKEND"),
+		RateLimitAlgorithm:        v.GetString("RATE_LIMIT_ALGORITHM"),
+		RateLimitKey:              v.GetString("RATE_LIMIT_KEY"),
+		RateLimitAPIKeyHeader:     v.GetString("RATE_LIMIT_API_KEY_HEADER"),
+		RateLimitRules:            rateLimitRules,
+		IdempotencyStore:          v.GetString("IDEMPOTENCY_STORE"),
+		IdempotencyTTL:            defDur(idemTTL, 24*time.Hour),
+		IdempotencyLockTTL:        defDur(idemLockTTL, time.Minute),
+		OTLPEndpoint:              v.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		OTelService:               v.GetString("OTEL_SERVICE_NAME"),
+		OTelEnv:                   v.GetString("OTEL_ENV"),
+		LogLevel:                  v.GetString("LOG_LEVEL"),
+		LogConsole:                v.GetBool("LOG_CONSOLE"),
+		LogFilePath:               v.GetString("LOG_FILE_PATH"),
+		LogFileMaxSizeMB:          v.GetInt("LOG_FILE_MAX_SIZE_MB"),
+		LogFileMaxBackups:         v.GetInt("LOG_FILE_MAX_BACKUPS"),
+		LogFileMaxAgeDays:         v.GetInt("LOG_FILE_MAX_AGE_DAYS"),
+		LogFileCompress:           v.GetBool("LOG_FILE_COMPRESS"),
+		LogStackAt:                v.GetString("LOG_STACK_AT"),
+		AccessLogSkipPaths:        splitList(v.GetString("ACCESS_LOG_SKIP_PATHS")),
+		AccessLogSampleRate:       v.GetFloat64("ACCESS_LOG_SAMPLE_RATE"),
+		AccessLogBodyMaxBytes:     v.GetInt("ACCESS_LOG_BODY_MAX_BYTES"),
+		AccessLogRedactHeaders:    splitList(v.GetString("ACCESS_LOG_REDACT_HEADERS")),
+		AccessLogRedactFields:     splitList(v.GetString("ACCESS_LOG_REDACT_FIELDS")),
+		TrafficRecordEnabled:      v.GetBool("TRAFFIC_RECORD_ENABLED"),
+		TrafficRecordFile:         v.GetString("TRAFFIC_RECORD_FILE"),
+		TrafficRecordSampleRate:   v.GetFloat64("TRAFFIC_RECORD_SAMPLE_RATE"),
+		TrafficRecordMaxBodyBytes: v.GetInt("TRAFFIC_RECORD_MAX_BODY_BYTES"),
+		TrafficRecordRedactFields: splitList(v.GetString("TRAFFIC_RECORD_REDACT_FIELDS")),
+		TrafficRecordMaxSizeMB:    v.GetInt("TRAFFIC_RECORD_MAX_SIZE_MB"),
+		TrafficRecordMaxBackups:   v.GetInt("TRAFFIC_RECORD_MAX_BACKUPS"),
+	}
+	if len(cfg.RedisAddrs) == 0 {
+		cfg.RedisAddrs = []string{cfg.RedisAddr}
+	}
+	if cfg.StreamGroup == "" {
+		cfg.StreamGroup = cfg.AppName
+	}
+	if cfg.NATSStream == "" {
+		cfg.NATSStream = strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", " ", "_").Replace(cfg.AppName))
+	}
+	if len(cfg.NATSSubjects) == 0 {
+		cfg.NATSSubjects = []string{cfg.AppName + ".>"}
+	}
+	if cfg.NATSDurable == "" {
+		cfg.NATSDurable = cfg.AppName
 	}
 	_ = os.Setenv("OTEL_SERVICE_NAME", cfg.OTelService)
 	return cfg, nil
@@ -115,3 +423,60 @@ func defDur(d, def time.Duration) time.Duration {
 	}
 	return d
 }
+
+// parseQueues : "default:10,mail:2" -> {"default": 10, "mail": 2}
+func parseQueues(s string) map[string]int {
+	out := map[string]int{}
+	for _, part := range strings.Split(s, ",") {
+		name, n, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if name == "" {
+			continue
+		}
+		c, err := strconv.Atoi(n)
+		if err != nil || c <= 0 {
+			c = 1
+		}
+		out[name] = c
+	}
+	if len(out) == 0 {
+		out["default"] = 1
+	}
+	return out
+}
+
+// RateLimitRule limits every path under Prefix to Requests per Per.
+type RateLimitRule struct {
+	Prefix   string
+	Requests int
+	Per      time.Duration
+}
+
+// parseRateLimitRules : "/v1=100/1m,/v1/webhooks=20/1m"
+func parseRateLimitRules(s string) ([]RateLimitRule, error) {
+	var out []RateLimitRule
+	for _, part := range splitList(s) {
+		prefix, limit, _ := strings.Cut(part, "=")
+		n, per, _ := strings.Cut(limit, "/")
+		reqs, err := strconv.Atoi(n)
+		if err != nil || reqs <= 0 || prefix == "" {
+			return nil, fmt.Errorf("config: RATE_LIMIT_RULES: invalid rule %q, want prefix=requests/period", part)
+		}
+		d, err := time.ParseDuration(per)
+		if err != nil || d < time.Millisecond {
+			return nil, fmt.Errorf("config: RATE_LIMIT_RULES: invalid period in %q", part)
+		}
+		out = append(out, RateLimitRule{Prefix: prefix, Requests: reqs, Per: d})
+	}
+	return out, nil
+}
+
+// splitList : "a, b,,c" -> ["a", "b", "c"]
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitRules(t *testing.T) {
+	rules, err := parseRateLimitRules("/v1=100/1m, /v1/webhooks=20/1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RateLimitRule{{"/v1", 100, time.Minute}, {"/v1/webhooks", 20, time.Second}}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	for _, bad := range []string{"/v1=100", "/v1=abc/1m", "=10/1m", "/v1=0/1m", "/v1=10/1ns", "/v1=10/soon"} {
+		if _, err := parseRateLimitRules(bad); err == nil {
+			t.Errorf("parseRateLimitRules(%q) accepted", bad)
+		}
+	}
+}
+
+func TestNewRejectsInvalidRateLimitRules(t *testing.T) {
+	t.Setenv("RATE_LIMIT_RULES", "/v1=100/minute")
+	if _, err := New(); err == nil {
+		t.Error("New accepted an invalid RATE_LIMIT_RULES")
+	}
+}
@@ -0,0 +1,14 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Discard closes conn without returning it to the pool. Use it for
+// connections holding session state (advisory locks) that could not be
+// cleaned up: the server drops that state when the connection ends.
+func Discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -15,14 +15,7 @@ import (
 
 func NewGorm(cfg *config.Config, log *zap.Logger) (*gorm.DB, error) {
 	gcfg := &gorm.Config{
-		Logger: logger.New(
-			zap.NewStdLog(log),
-			logger.Config{
-				SlowThreshold: 200 * time.Millisecond,
-				LogLevel:      logger.Warn,
-				Colorful:      false,
-			},
-		),
+		Logger: newGormLogger(logger.Warn, 200*time.Millisecond),
 	}
 	db, err := gorm.Open(postgres.Open(cfg.DBDSN), gcfg)
 	if err != nil {
@@ -43,9 +36,9 @@ func NewGorm(cfg *config.Config, log *zap.Logger) (*gorm.DB, error) {
 
 func RegisterHooks(lc fx.Lifecycle, gdb *gorm.DB, log *zap.Logger) {
 	lc.Append(fx.Hook{
-		OnStart: func(_ context.Context) error {
+		OnStart: func(ctx context.Context) error {
 			sqlDB, _ := gdb.DB()
-			return sqlDB.Ping()
+			return sqlDB.PingContext(ctx)
 		},
 		OnStop: func(_ context.Context) error {
 			sqlDB, _ := gdb.DB()
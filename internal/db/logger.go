@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	applog "microseed/internal/log"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// gormLogger writes GORM logs through the request-scoped logger, so query
+// errors and slow queries carry request_id / trace_id like the access log.
+type gormLogger struct {
+	level logger.LogLevel
+	slow  time.Duration
+}
+
+func newGormLogger(level logger.LogLevel, slow time.Duration) logger.Interface {
+	return gormLogger{level: level, slow: slow}
+}
+
+func (l gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	l.level = level
+	return l
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Info {
+		l.log(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Warn {
+		l.log(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= logger.Error {
+		l.log(ctx).Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.log(ctx).Error("query failed", l.fields(sql, rows, elapsed, zap.Error(err))...)
+	case l.slow > 0 && elapsed > l.slow && l.level >= logger.Warn:
+		sql, rows := fc()
+		l.log(ctx).Warn("slow query", l.fields(sql, rows, elapsed, zap.Duration("threshold", l.slow))...)
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		l.log(ctx).Debug("query", l.fields(sql, rows, elapsed)...)
+	}
+}
+
+func (l gormLogger) log(ctx context.Context) *zap.Logger {
+	return applog.FromContext(ctx).Named("gorm").WithOptions(zap.WithCaller(false))
+}
+
+func (gormLogger) fields(sql string, rows int64, elapsed time.Duration, extra ...zap.Field) []zap.Field {
+	return append([]zap.Field{
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", elapsed),
+		zap.String("source", utils.FileWithLineNum()),
+	}, extra...)
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Checker is a readiness dependency. Modules contribute checkers with
+// fx.ResultTags(`group:"health"`).
+type Checker interface {
+	Name() string
+	Options() CheckOptions
+	Check(ctx context.Context) error
+}
+
+type CheckOptions struct {
+	Critical bool          // a failing critical check makes /readyz return 503
+	Timeout  time.Duration // per run
+	CacheTTL time.Duration // reuse the last result for this long (0 = always run)
+}
+
+type CheckOption func(*CheckOptions)
+
+// NonCritical reports failures as "degraded" instead of failing readiness.
+func NonCritical() CheckOption {
+	return func(o *CheckOptions) { o.Critical = false }
+}
+
+func Timeout(d time.Duration) CheckOption {
+	return func(o *CheckOptions) { o.Timeout = d }
+}
+
+func CacheTTL(d time.Duration) CheckOption {
+	return func(o *CheckOptions) { o.CacheTTL = d }
+}
+
+type checker struct {
+	name string
+	opts CheckOptions
+	fn   func(ctx context.Context) error
+}
+
+func (c checker) Name() string                    { return c.name }
+func (c checker) Options() CheckOptions           { return c.opts }
+func (c checker) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// NewChecker builds a critical Checker with a 1s timeout unless overridden.
+func NewChecker(name string, fn func(ctx context.Context) error, opts ...CheckOption) Checker {
+	o := CheckOptions{Critical: true, Timeout: time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return checker{name: name, opts: o, fn: fn}
+}
@@ -0,0 +1,32 @@
+package health
+
+import (
+	"context"
+
+	"microseed/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+func NewDBChecker(db *gorm.DB) Checker {
+	return NewChecker("postgres", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+}
+
+// NewRedisChecker is critical only when REDIS_REQUIRED=true; otherwise an
+// outage shows up as "degraded".
+func NewRedisChecker(rdb redis.UniversalClient, cfg *config.Config) Checker {
+	var opts []CheckOption
+	if !cfg.RedisRequired {
+		opts = append(opts, NonCritical())
+	}
+	return NewChecker("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}, opts...)
+}
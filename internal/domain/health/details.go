@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"microseed/internal/buildinfo"
+	"microseed/internal/migrate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// details : /healthz/details (admin) — check history plus dependency metadata.
+func (h *Handler) details(c *gin.Context) {
+	ctx := c.Request.Context()
+	rep := h.Registry.Run(ctx)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   rep.Status,
+		"build":    buildinfo.Get(),
+		"checks":   h.Registry.Details(),
+		"postgres": h.postgresInfo(ctx),
+		"redis":    h.redisInfo(),
+		"ts":       rep.TS,
+	})
+}
+
+func (h *Handler) postgresInfo(ctx context.Context) gin.H {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	out := gin.H{}
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		out["error"] = err.Error()
+		return out
+	}
+	var version string
+	if err := sqlDB.QueryRowContext(ctx, "SHOW server_version").Scan(&version); err != nil {
+		out["version_error"] = err.Error()
+	} else {
+		out["version"] = version
+	}
+	if v, err := migrate.CurrentVersion(ctx, sqlDB); err != nil {
+		out["migration_error"] = err.Error()
+	} else {
+		out["migration_version"] = v
+	}
+
+	s := sqlDB.Stats()
+	out["pool"] = gin.H{
+		"max_open":             s.MaxOpenConnections,
+		"open":                 s.OpenConnections,
+		"in_use":               s.InUse,
+		"idle":                 s.Idle,
+		"wait_count":           s.WaitCount,
+		"wait_duration_ms":     s.WaitDuration.Milliseconds(),
+		"max_idle_closed":      s.MaxIdleClosed,
+		"max_idle_time_closed": s.MaxIdleTimeClosed,
+		"max_lifetime_closed":  s.MaxLifetimeClosed,
+	}
+	return out
+}
+
+func (h *Handler) redisInfo() gin.H {
+	s := h.RDB.PoolStats()
+	return gin.H{
+		"pool": gin.H{
+			"hits":        s.Hits,
+			"misses":      s.Misses,
+			"timeouts":    s.Timeouts,
+			"total_conns": s.TotalConns,
+			"idle_conns":  s.IdleConns,
+			"stale_conns": s.StaleConns,
+		},
+	}
+}
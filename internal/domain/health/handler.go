@@ -1,9 +1,11 @@
 package health
 
 import (
-	"context"
 	"net/http"
-	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/httpx"
+	"microseed/internal/lifecycle"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -12,42 +14,53 @@ import (
 )
 
 type Handler struct {
-	DB  *gorm.DB
-	RDB *redis.Client
-	Log *zap.Logger
+	Registry   *Registry
+	State      *lifecycle.State
+	DB         *gorm.DB
+	RDB        redis.UniversalClient
+	AdminToken string
+	Log        *zap.Logger
 }
 
-func NewHandler(db *gorm.DB, rdb *redis.Client, log *zap.Logger) *Handler {
-	return &Handler{DB: db, RDB: rdb, Log: log}
+func NewHandler(reg *Registry, state *lifecycle.State, db *gorm.DB, rdb redis.UniversalClient, cfg *config.Config, log *zap.Logger) *Handler {
+	return &Handler{Registry: reg, State: state, DB: db, RDB: rdb, AdminToken: cfg.AdminToken, Log: log}
 }
 
 func (h *Handler) Register(r *gin.Engine) {
 	r.GET("/healthz", h.liveness)
 	r.GET("/readyz", h.readiness)
+	r.GET("/startupz", h.startup)
+	r.GET("/healthz/details", httpx.AdminAuth(h.AdminToken), h.details)
 }
 
 func (h *Handler) liveness(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok", "type": "liveness"})
 }
 
+// readiness : 503 only when a critical check fails; non-critical failures
+// are reported as "degraded" with 200.
 func (h *Handler) readiness(c *gin.Context) {
-	// DB check
-	sqlDB, _ := h.DB.DB()
-	dbOK := (sqlDB.Ping() == nil)
-
-	// Redis check
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 200*time.Millisecond)
-	defer cancel()
-	_, rerr := h.RDB.Ping(ctx).Result()
-	redisOK := rerr == nil
-
+	switch {
+	case h.State.ShuttingDown():
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	case !h.State.Started():
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "starting"})
+		return
+	}
+	rep := h.Registry.Run(c.Request.Context())
 	status := http.StatusOK
-	if !dbOK || !redisOK {
+	if rep.Status == StatusDown {
 		status = http.StatusServiceUnavailable
 	}
-	c.JSON(status, gin.H{
-		"db":    dbOK,
-		"redis": redisOK,
-		"ts":    time.Now().Format(time.RFC3339),
-	})
+	c.JSON(status, rep)
+}
+
+// startup : green once every fx OnStart hook (migrations, warmup, ...) has run.
+func (h *Handler) startup(c *gin.Context) {
+	if !h.State.Started() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "starting"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "type": "startup"})
 }
@@ -6,8 +6,13 @@ import (
 	"go.uber.org/fx"
 )
 
+// Module serves /healthz and /readyz. Checkers are contributed with
+// fx.ResultTags(`group:"health"`).
 var Module = fx.Options(
+	fx.Provide(NewRegistry),
 	fx.Provide(
+		fx.Annotate(NewDBChecker, fx.ResultTags(`group:"health"`)),
+		fx.Annotate(NewRedisChecker, fx.ResultTags(`group:"health"`)),
 		fx.Annotate(
 			NewHandler,
 			fx.As(new(httpx.RouteRegistrar)),
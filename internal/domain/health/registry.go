@@ -0,0 +1,240 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+
+	historySize = 20
+)
+
+type Result struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Latency   time.Duration `json:"-"`
+	LatencyMS float64       `json:"latency_ms"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Cached    bool          `json:"cached,omitempty"`
+}
+
+type Report struct {
+	Status string    `json:"status"` // ok | degraded | down
+	Checks []Result  `json:"checks"`
+	TS     time.Time `json:"ts"`
+}
+
+// Detail summarizes the recent history of one checker.
+type Detail struct {
+	Name             string     `json:"name"`
+	Critical         bool       `json:"critical"`
+	Status           string     `json:"status"`
+	LastSuccess      *time.Time `json:"last_success,omitempty"`
+	SinceLastSuccess string     `json:"since_last_success,omitempty"`
+	P50MS            float64    `json:"p50_ms"`
+	P99MS            float64    `json:"p99_ms"`
+	History          []Result   `json:"history"` // newest first
+}
+
+type checkState struct {
+	history     []Result // oldest first, at most historySize
+	lastSuccess time.Time
+	failing     bool
+}
+
+// Registry runs all contributed checkers in parallel and keeps the last
+// historySize results of each. A checker's first failure and its recovery
+// are logged once, not on every poll.
+type Registry struct {
+	checkers []Checker
+	log      *zap.Logger
+
+	mu     sync.Mutex
+	states map[string]*checkState
+}
+
+type registryIn struct {
+	fx.In
+
+	Log      *zap.Logger
+	Checkers []Checker `group:"health"`
+}
+
+func NewRegistry(in registryIn) *Registry {
+	return &Registry{
+		checkers: in.Checkers,
+		log:      in.Log.Named("health"),
+		states:   map[string]*checkState{},
+	}
+}
+
+func (r *Registry) Checkers() []Checker { return r.checkers }
+
+// Run executes every checker (or reuses a cached result) and aggregates:
+// any critical failure is "down", only non-critical failures "degraded".
+func (r *Registry) Run(ctx context.Context) Report {
+	results := make([]Result, len(r.checkers))
+	var wg sync.WaitGroup
+	for i, c := range r.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusOK, Checks: results, TS: time.Now()}
+	for _, res := range results {
+		if res.Status == StatusUp {
+			continue
+		}
+		if res.Critical {
+			rep.Status = StatusDown
+			break
+		}
+		rep.Status = StatusDegraded
+	}
+	return rep
+}
+
+// Details returns the history and latency percentiles of every checker.
+func (r *Registry) Details() []Detail {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Detail, 0, len(r.checkers))
+	for _, c := range r.checkers {
+		d := Detail{Name: c.Name(), Critical: c.Options().Critical, Status: "unknown", History: []Result{}}
+		st, ok := r.states[c.Name()]
+		if ok && len(st.history) > 0 {
+			d.Status = st.history[len(st.history)-1].Status
+			latencies := make([]time.Duration, 0, len(st.history))
+			for i := len(st.history) - 1; i >= 0; i-- {
+				d.History = append(d.History, st.history[i])
+				latencies = append(latencies, st.history[i].Latency)
+			}
+			slices.Sort(latencies)
+			d.P50MS = ms(percentile(latencies, 0.50))
+			d.P99MS = ms(percentile(latencies, 0.99))
+			if !st.lastSuccess.IsZero() {
+				last := st.lastSuccess
+				d.LastSuccess = &last
+				d.SinceLastSuccess = time.Since(last).Round(time.Millisecond).String()
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func (r *Registry) run(ctx context.Context, c Checker) Result {
+	opts := c.Options()
+	if opts.CacheTTL > 0 {
+		r.mu.Lock()
+		st, ok := r.states[c.Name()]
+		var res Result
+		if ok && len(st.history) > 0 {
+			res = st.history[len(st.history)-1]
+		}
+		r.mu.Unlock()
+		if ok && time.Since(res.CheckedAt) < opts.CacheTTL {
+			res.Cached = true
+			return res
+		}
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := safeCheck(ctx, c)
+	res := Result{
+		Name:      c.Name(),
+		Status:    StatusUp,
+		Critical:  opts.Critical,
+		Latency:   time.Since(start),
+		CheckedAt: start,
+	}
+	res.LatencyMS = ms(res.Latency)
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	r.record(res)
+	return res
+}
+
+func (r *Registry) record(res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, ok := r.states[res.Name]
+	if !ok {
+		st = &checkState{}
+		r.states[res.Name] = st
+	}
+	st.history = append(st.history, res)
+	if len(st.history) > historySize {
+		st.history = st.history[len(st.history)-historySize:]
+	}
+
+	switch {
+	case res.Status == StatusDown && !st.failing:
+		st.failing = true
+		r.log.Warn("health check failing",
+			zap.String("check", res.Name),
+			zap.Bool("critical", res.Critical),
+			zap.String("error", res.Error),
+		)
+	case res.Status == StatusUp && st.failing:
+		st.failing = false
+		fields := []zap.Field{zap.String("check", res.Name)}
+		if !st.lastSuccess.IsZero() {
+			fields = append(fields, zap.Duration("down_for", res.CheckedAt.Sub(st.lastSuccess)))
+		}
+		r.log.Info("health check recovered", fields...)
+	}
+	if res.Status == StatusUp {
+		st.lastSuccess = res.CheckedAt
+	}
+}
+
+func safeCheck(ctx context.Context, c Checker) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v\n%s", rec, debug.Stack())
+		}
+	}()
+	return c.Check(ctx)
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(float64(len(sorted))*p)) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,216 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestRegistry(checkers ...Checker) *Registry {
+	return NewRegistry(registryIn{Log: zap.NewNop(), Checkers: checkers})
+}
+
+func up(name string, opts ...CheckOption) Checker {
+	return NewChecker(name, func(context.Context) error { return nil }, opts...)
+}
+
+func down(name string, opts ...CheckOption) Checker {
+	return NewChecker(name, func(context.Context) error { return errors.New(name + " unreachable") }, opts...)
+}
+
+func TestRegistryAggregation(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkers []Checker
+		want     string
+	}{
+		{"all up", []Checker{up("db"), up("redis", NonCritical())}, StatusOK},
+		{"non-critical down", []Checker{up("db"), down("redis", NonCritical())}, StatusDegraded},
+		{"critical down", []Checker{down("db"), up("redis", NonCritical())}, StatusDown},
+		{"critical wins over degraded", []Checker{down("redis", NonCritical()), down("db")}, StatusDown},
+		{"no checkers", nil, StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := newTestRegistry(tt.checkers...).Run(context.Background())
+			if rep.Status != tt.want {
+				t.Errorf("status = %q, want %q", rep.Status, tt.want)
+			}
+			if len(rep.Checks) != len(tt.checkers) {
+				t.Fatalf("checks = %d, want %d", len(rep.Checks), len(tt.checkers))
+			}
+			for i, res := range rep.Checks {
+				if res.Name != tt.checkers[i].Name() || res.Critical != tt.checkers[i].Options().Critical {
+					t.Errorf("checks[%d] = %+v", i, res)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	slow := NewChecker("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Timeout(20*time.Millisecond))
+	r := newTestRegistry(slow, up("fast"))
+
+	start := time.Now()
+	rep := r.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("run took %s, timeout not applied", elapsed)
+	}
+	res := rep.Checks[0]
+	if res.Status != StatusDown || !strings.Contains(res.Error, context.DeadlineExceeded.Error()) {
+		t.Errorf("slow = %+v, want down with deadline exceeded", res)
+	}
+	if rep.Checks[1].Status != StatusUp {
+		t.Errorf("fast = %+v", rep.Checks[1])
+	}
+}
+
+func TestRegistryCacheTTL(t *testing.T) {
+	var calls atomic.Int32
+	c := NewChecker("db", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}, CacheTTL(50*time.Millisecond))
+	r := newTestRegistry(c)
+	ctx := context.Background()
+
+	first := r.Run(ctx).Checks[0]
+	second := r.Run(ctx).Checks[0]
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("calls = %d, want 1 within the ttl", n)
+	}
+	if first.Cached || !second.Cached || !second.CheckedAt.Equal(first.CheckedAt) {
+		t.Errorf("first = %+v, second = %+v", first, second)
+	}
+	if n := len(r.Details()[0].History); n != 1 {
+		t.Errorf("history = %d, cached results must not be recorded", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if third := r.Run(ctx).Checks[0]; third.Cached {
+		t.Errorf("third = %+v, want a fresh run after the ttl", third)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2", n)
+	}
+}
+
+func TestRegistryRecoversPanics(t *testing.T) {
+	boom := NewChecker("boom", func(context.Context) error { panic("kaboom") })
+	r := newTestRegistry(boom, up("db"))
+
+	rep := r.Run(context.Background())
+	if rep.Status != StatusDown {
+		t.Errorf("status = %q, want down", rep.Status)
+	}
+	if res := rep.Checks[0]; res.Status != StatusDown || !strings.HasPrefix(res.Error, "panic: kaboom") {
+		t.Errorf("boom = %+v", res)
+	}
+	if rep.Checks[1].Status != StatusUp {
+		t.Errorf("db = %+v", rep.Checks[1])
+	}
+}
+
+func TestRecordLogsTransitionsOnce(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	var failing atomic.Bool
+	c := NewChecker("db", func(context.Context) error {
+		if failing.Load() {
+			return errors.New("refused")
+		}
+		return nil
+	})
+	r := NewRegistry(registryIn{Log: zap.New(core), Checkers: []Checker{c}})
+	ctx := context.Background()
+
+	r.Run(ctx)
+	failing.Store(true)
+	r.Run(ctx)
+	r.Run(ctx)
+	failing.Store(false)
+	r.Run(ctx)
+	r.Run(ctx)
+
+	if n := logs.FilterMessage("health check failing").Len(); n != 1 {
+		t.Errorf("failing logged %d times, want 1", n)
+	}
+	recovered := logs.FilterMessage("health check recovered").All()
+	if len(recovered) != 1 {
+		t.Fatalf("recovered logged %d times, want 1", len(recovered))
+	}
+	if _, ok := recovered[0].ContextMap()["down_for"]; !ok {
+		t.Errorf("recovery log = %v, want down_for", recovered[0].ContextMap())
+	}
+	if logs.Len() != 2 {
+		t.Errorf("logs = %d, want 2", logs.Len())
+	}
+}
+
+func TestRecordCapsHistory(t *testing.T) {
+	var n atomic.Int32
+	c := NewChecker("db", func(context.Context) error {
+		if n.Add(1)%2 == 0 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	r := newTestRegistry(c)
+	for range historySize + 6 {
+		r.Run(context.Background())
+	}
+
+	d := r.Details()[0]
+	if len(d.History) != historySize {
+		t.Fatalf("history = %d, want %d", len(d.History), historySize)
+	}
+	for i := 1; i < len(d.History); i++ {
+		if d.History[i].CheckedAt.After(d.History[i-1].CheckedAt) {
+			t.Fatalf("history not newest first at %d", i)
+		}
+	}
+	// even runs fail, so the newest entry (run 26) is down
+	if d.Status != StatusDown || d.LastSuccess == nil || !d.LastSuccess.Equal(d.History[1].CheckedAt) {
+		t.Errorf("status = %q, last success = %v, want down after the success at %v", d.Status, d.LastSuccess, d.History[1].CheckedAt)
+	}
+}
+
+func TestPercentileNearestRank(t *testing.T) {
+	sorted := make([]time.Duration, 10)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * time.Millisecond},
+		{0.1, 1 * time.Millisecond},
+		{0.14, 2 * time.Millisecond},
+		{0.5, 5 * time.Millisecond},
+		{0.51, 6 * time.Millisecond},
+		{0.9, 9 * time.Millisecond},
+		{0.99, 10 * time.Millisecond},
+		{1, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %s, want %s", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile(nil) = %s", got)
+	}
+	if got := percentile(sorted[:1], 0.99); got != time.Millisecond {
+		t.Errorf("single value = %s", got)
+	}
+}
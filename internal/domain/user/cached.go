@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"microseed/internal/cache"
+	"microseed/internal/config"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+// cachedUser is the cache entry; Missing marks a negative (not found) entry.
+type cachedUser struct {
+	User    *Entity `json:"user,omitempty" msgpack:"user,omitempty"`
+	Missing bool    `json:"missing,omitempty" msgpack:"missing,omitempty"`
+}
+
+// redeleteAfter is when a write invalidates its key a second time, for
+// loads (here or on another replica) that read the old row before the
+// commit and fill the cache after the first delete.
+const redeleteAfter = 2 * time.Second
+
+// cachedService is a read-through decorator for GetByID. Concurrent misses
+// for the same ID share one load; writes invalidate the entry.
+type cachedService struct {
+	Service
+	users  *cache.Typed[cachedUser]
+	ttl    time.Duration
+	negTTL time.Duration
+	group  singleflight.Group
+
+	// bumped by every write; a load only fills the cache when the
+	// generation of its key did not change while it read the row
+	gens [64]atomic.Uint64
+
+	hits   metric.Int64Counter
+	misses metric.Int64Counter
+}
+
+// NewCachedService decorates next when USER_CACHE_ENABLED is set and
+// returns it unchanged otherwise.
+func NewCachedService(next Service, c cache.Cache, codec cache.Codec, cfg *config.Config) (Service, error) {
+	if !cfg.UserCacheEnabled {
+		return next, nil
+	}
+	s := &cachedService{
+		Service: next,
+		users:   cache.NewTyped[cachedUser](c, codec, "user:"),
+		ttl:     cfg.UserCacheTTL,
+		negTTL:  cfg.UserCacheNegativeTTL,
+	}
+	meter := otel.Meter("microseed/user")
+	var err error
+	if s.hits, err = meter.Int64Counter("user.cache.hits"); err != nil {
+		return nil, err
+	}
+	if s.misses, err = meter.Int64Counter("user.cache.misses"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *cachedService) GetByID(ctx context.Context, id uuid.UUID) (*Entity, error) {
+	span := trace.SpanFromContext(ctx)
+	key := id.String()
+
+	if v, err := s.users.Get(ctx, key); err == nil {
+		s.hits.Add(ctx, 1)
+		span.SetAttributes(attribute.Bool("cache.hit", true), attribute.Bool("cache.negative", v.Missing))
+		if v.Missing {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return v.User, nil
+	}
+	s.misses.Add(ctx, 1)
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+
+	v, err, shared := s.group.Do(key, func() (any, error) {
+		// detached: one caller giving up must not fail the others
+		lctx := context.WithoutCancel(ctx)
+		gen := s.gen(key).Load()
+		u, err := s.Service.GetByID(lctx, id)
+		if s.gen(key).Load() != gen {
+			return u, err // written meanwhile: u may be stale, don't cache it
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if s.negTTL > 0 {
+				_ = s.users.Fill(lctx, key, cachedUser{Missing: true}, s.negTTL)
+			}
+		case err == nil:
+			_ = s.users.Fill(lctx, key, cachedUser{User: u}, s.ttl)
+		}
+		return u, err
+	})
+	span.SetAttributes(attribute.Bool("cache.shared_load", shared))
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Entity), nil
+}
+
+func (s *cachedService) Create(ctx context.Context, email string) (*Entity, error) {
+	u, err := s.Service.Create(ctx, email)
+	if err == nil {
+		s.invalidate(ctx, u.ID.String()) // drop a stale negative entry, if any
+	}
+	return u, err
+}
+
+func (s *cachedService) Update(ctx context.Context, id uuid.UUID, email string) (*Entity, error) {
+	u, err := s.Service.Update(ctx, id, email)
+	if err == nil {
+		s.invalidate(ctx, id.String())
+	}
+	return u, err
+}
+
+func (s *cachedService) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.Service.Delete(ctx, id)
+	if err == nil {
+		s.invalidate(ctx, id.String())
+	}
+	return err
+}
+
+// invalidate runs after the write committed. Loads in flight here are
+// kept from caching (generation) and later callers start a fresh one
+// (Forget); the delayed delete covers loads on other replicas.
+func (s *cachedService) invalidate(ctx context.Context, key string) {
+	s.gen(key).Add(1)
+	s.group.Forget(key)
+	_ = s.users.Delete(ctx, key)
+	bg := context.WithoutCancel(ctx)
+	time.AfterFunc(redeleteAfter, func() { _ = s.users.Delete(bg, key) })
+}
+
+func (s *cachedService) gen(key string) *atomic.Uint64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &s.gens[h.Sum32()%uint32(len(s.gens))]
+}
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"microseed/internal/cache"
+	"microseed/internal/config"
+	"microseed/internal/events"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// stubService serves GetByID from a map; block, when set, holds the read
+// after the row was copied, like a slow query returning an old snapshot.
+type stubService struct {
+	Service
+	mu    sync.Mutex
+	rows  map[uuid.UUID]Entity
+	loads atomic.Int32
+	block chan struct{}
+}
+
+func (s *stubService) GetByID(_ context.Context, id uuid.UUID) (*Entity, error) {
+	s.loads.Add(1)
+	s.mu.Lock()
+	u, ok := s.rows[id]
+	block := s.block
+	s.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &u, nil
+}
+
+func (s *stubService) Update(_ context.Context, id uuid.UUID, email string) (*Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.rows[id]
+	u.Email = email
+	s.rows[id] = u
+	return &u, nil
+}
+
+func newTestCached(t *testing.T, next Service) Service {
+	t.Helper()
+	svc, err := NewCachedService(next, cache.NewMemory(0, 0), cache.JSON, &config.Config{
+		UserCacheEnabled:     true,
+		UserCacheTTL:         time.Minute,
+		UserCacheNegativeTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestCachedServiceDisabledByDefault(t *testing.T) {
+	next := &stubService{}
+	svc, err := NewCachedService(next, cache.NewMemory(0, 0), cache.JSON, &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc != Service(next) {
+		t.Errorf("got %T, want the undecorated service", svc)
+	}
+}
+
+func TestCachedServiceReadThrough(t *testing.T) {
+	id := uuid.New()
+	next := &stubService{rows: map[uuid.UUID]Entity{id: {ID: id, Email: "a@example.com"}}}
+	svc := newTestCached(t, next)
+	ctx := context.Background()
+
+	for range 3 {
+		u, err := svc.GetByID(ctx, id)
+		if err != nil || u.Email != "a@example.com" {
+			t.Fatalf("GetByID = %+v, %v", u, err)
+		}
+	}
+	missing := uuid.New()
+	for range 2 {
+		if _, err := svc.GetByID(ctx, missing); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("GetByID(missing) err = %v", err)
+		}
+	}
+	if n := next.loads.Load(); n != 2 {
+		t.Errorf("loads = %d, want 2 (one per id)", n)
+	}
+
+	if _, err := svc.Update(ctx, id, "b@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	u, err := svc.GetByID(ctx, id)
+	if err != nil || u.Email != "b@example.com" {
+		t.Errorf("GetByID after Update = %+v, %v", u, err)
+	}
+}
+
+func TestCachedServiceLoadOverlappingUpdateIsNotCached(t *testing.T) {
+	id := uuid.New()
+	block := make(chan struct{})
+	next := &stubService{rows: map[uuid.UUID]Entity{id: {ID: id, Email: "old@example.com"}}, block: block}
+	svc := newTestCached(t, next)
+	ctx := context.Background()
+
+	loaded := make(chan *Entity)
+	go func() {
+		u, _ := svc.GetByID(ctx, id) // reads the old row, then blocks
+		loaded <- u
+	}()
+	for next.loads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := svc.Update(ctx, id, "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	next.mu.Lock()
+	next.block = nil
+	next.mu.Unlock()
+	close(block)
+	if u := <-loaded; u.Email != "old@example.com" {
+		t.Fatalf("in-flight load returned %q", u.Email)
+	}
+
+	u, err := svc.GetByID(ctx, id)
+	if err != nil || u.Email != "new@example.com" {
+		t.Errorf("GetByID after overlapping load = %+v, %v; stale row was cached", u, err)
+	}
+}
+
+func TestCachedServiceWithDatabase(t *testing.T) {
+	svc := newTestCached(t, NewService(newTestDB(t), events.NewRecordingBus()))
+	ctx := context.Background()
+
+	id := uuid.New()
+	if _, err := svc.GetByID(ctx, id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+	u, err := svc.Create(ctx, "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetByID(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetByID(ctx, u.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByID after Delete err = %v, want ErrRecordNotFound", err)
+	}
+}
@@ -0,0 +1,36 @@
+package user
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TopicCreated = "user.created"
+	TopicUpdated = "user.updated"
+	TopicDeleted = "user.deleted"
+)
+
+type Created struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Created) EventName() string { return TopicCreated }
+
+type Updated struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Updated) EventName() string { return TopicUpdated }
+
+type Deleted struct {
+	ID        uuid.UUID `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (Deleted) EventName() string { return TopicDeleted }
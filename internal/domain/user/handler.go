@@ -3,6 +3,9 @@ package user
 import (
 	"net/http"
 
+	"microseed/internal/apperr"
+	"microseed/internal/httpx"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -23,12 +26,12 @@ func (h *Handler) Register(r *gin.Engine) {
 func (h *Handler) getByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		httpx.Fail(c, apperr.Validation("invalid id"))
 		return
 	}
 	u, err := h.Svc.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		httpx.Fail(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, u)
@@ -6,6 +6,7 @@ import (
 	"go.uber.org/fx"
 )
 
+// Module provides the plain (uncached) Service and the /v1/users routes.
 var Module = fx.Options(
 	fx.Provide(NewService),
 	fx.Provide(
@@ -16,3 +17,7 @@ var Module = fx.Options(
 		),
 	),
 )
+
+// CachedModule wraps the Service with the read-through cache decorator
+// when USER_CACHE_ENABLED is set.
+var CachedModule = fx.Decorate(NewCachedService)
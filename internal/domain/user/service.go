@@ -2,12 +2,22 @@ package user
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"microseed/internal/apperr"
+	"microseed/internal/events"
+	applog "microseed/internal/log"
+	"microseed/internal/outbox"
+
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+var ErrEmailTaken = apperr.Conflict("email already registered")
+
 type Entity struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
 	Email     string    `gorm:"uniqueIndex;not null"`
@@ -18,14 +28,18 @@ func (Entity) TableName() string { return "users" }
 
 type Service interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*Entity, error)
+	Create(ctx context.Context, email string) (*Entity, error)
+	Update(ctx context.Context, id uuid.UUID, email string) (*Entity, error)
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type serviceImpl struct {
-	DB *gorm.DB
+	DB  *gorm.DB
+	Bus events.Bus
 }
 
-func NewService(db *gorm.DB) Service {
-	return &serviceImpl{DB: db}
+func NewService(db *gorm.DB, bus events.Bus) Service {
+	return &serviceImpl{DB: db, Bus: bus}
 }
 
 func (s *serviceImpl) GetByID(ctx context.Context, id uuid.UUID) (*Entity, error) {
@@ -35,3 +49,76 @@ func (s *serviceImpl) GetByID(ctx context.Context, id uuid.UUID) (*Entity, error
 	}
 	return &u, nil
 }
+
+// Create inserts the user and its outbox message in one transaction.
+func (s *serviceImpl) Create(ctx context.Context, email string) (*Entity, error) {
+	u := &Entity{ID: uuid.New(), Email: email, CreatedAt: time.Now()}
+	evt := Created{ID: u.ID, Email: u.Email, CreatedAt: u.CreatedAt}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		return outbox.Add(tx, TopicCreated, u.ID.String(), evt)
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	applog.FromContext(ctx).Info("user created", zap.Stringer("user_id", u.ID))
+	s.emit(ctx, evt)
+	return u, nil
+}
+
+func (s *serviceImpl) Update(ctx context.Context, id uuid.UUID, email string) (*Entity, error) {
+	var u Entity
+	evt := Updated{ID: id, Email: email, UpdatedAt: time.Now()}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&u, "id = ?", id).Error; err != nil {
+			return err
+		}
+		u.Email = email
+		if err := tx.Model(&u).Update("email", email).Error; err != nil {
+			return err
+		}
+		return outbox.Add(tx, TopicUpdated, id.String(), evt)
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	applog.FromContext(ctx).Info("user updated", zap.Stringer("user_id", id))
+	s.emit(ctx, evt)
+	return &u, nil
+}
+
+func (s *serviceImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	evt := Deleted{ID: id, DeletedAt: time.Now()}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&Entity{}, "id = ?", id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return outbox.Add(tx, TopicDeleted, id.String(), evt)
+	})
+	if err != nil {
+		return err
+	}
+	applog.FromContext(ctx).Info("user deleted", zap.Stringer("user_id", id))
+	s.emit(ctx, evt)
+	return nil
+}
+
+// emit notifies in-process subscribers after commit. Subscriber failures are
+// logged by the bus; the change itself is already durable.
+func (s *serviceImpl) emit(ctx context.Context, evt events.Event) {
+	_ = s.Bus.Publish(ctx, evt)
+}
+
+func translate(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"microseed/internal/events"
+	"microseed/internal/outbox"
+	"microseed/internal/testutil"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// newTestDB opens a private in-memory SQLite database with the tables the
+// service writes to.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.SQLite(t)
+	err := db.Exec(`CREATE TABLE users (
+		id TEXT PRIMARY KEY,
+		email TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL
+	)`).Error
+	if err == nil {
+		err = db.AutoMigrate(&outbox.Message{})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestService(t *testing.T) (Service, *events.RecordingBus, *gorm.DB) {
+	db := newTestDB(t)
+	bus := events.NewRecordingBus()
+	return NewService(db, bus), bus, db
+}
+
+func topics(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var out []string
+	if err := db.Model(&outbox.Message{}).Order("created_at").Pluck("topic", &out).Error; err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestServiceCreateEmitsCreated(t *testing.T) {
+	svc, bus, db := newTestService(t)
+
+	u, err := svc.Create(context.Background(), "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := events.Recorded[Created](bus)
+	if len(got) != 1 {
+		t.Fatalf("events = %v, want one %s", bus.Names(), TopicCreated)
+	}
+	if got[0].ID != u.ID || got[0].Email != "a@example.com" || !got[0].CreatedAt.Equal(u.CreatedAt) {
+		t.Errorf("event = %+v, user = %+v", got[0], u)
+	}
+	if tp := topics(t, db); len(tp) != 1 || tp[0] != TopicCreated {
+		t.Errorf("outbox topics = %v", tp)
+	}
+}
+
+func TestServiceUpdateEmitsUpdated(t *testing.T) {
+	svc, bus, db := newTestService(t)
+	ctx := context.Background()
+	u, err := svc.Create(ctx, "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Reset()
+
+	if _, err := svc.Update(ctx, u.ID, "b@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	got := events.Recorded[Updated](bus)
+	if len(got) != 1 || len(bus.Events()) != 1 {
+		t.Fatalf("events = %v, want one %s", bus.Names(), TopicUpdated)
+	}
+	if got[0].ID != u.ID || got[0].Email != "b@example.com" || got[0].UpdatedAt.IsZero() {
+		t.Errorf("event = %+v", got[0])
+	}
+	if tp := topics(t, db); len(tp) != 2 || tp[1] != TopicUpdated {
+		t.Errorf("outbox topics = %v", tp)
+	}
+}
+
+func TestServiceDeleteEmitsDeleted(t *testing.T) {
+	svc, bus, db := newTestService(t)
+	ctx := context.Background()
+	u, err := svc.Create(ctx, "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Reset()
+
+	if err := svc.Delete(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	got := events.Recorded[Deleted](bus)
+	if len(got) != 1 || len(bus.Events()) != 1 {
+		t.Fatalf("events = %v, want one %s", bus.Names(), TopicDeleted)
+	}
+	if got[0].ID != u.ID || got[0].DeletedAt.IsZero() {
+		t.Errorf("event = %+v", got[0])
+	}
+	if tp := topics(t, db); len(tp) != 2 || tp[1] != TopicDeleted {
+		t.Errorf("outbox topics = %v", tp)
+	}
+}
+
+func TestServiceFailedChangesEmitNothing(t *testing.T) {
+	svc, bus, db := newTestService(t)
+	ctx := context.Background()
+	missing := uuid.New()
+
+	if _, err := svc.Update(ctx, missing, "b@example.com"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Update err = %v, want ErrRecordNotFound", err)
+	}
+	if err := svc.Delete(ctx, missing); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Delete err = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := svc.Create(ctx, "a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	bus.Reset()
+	if _, err := svc.Create(ctx, "a@example.com"); err == nil {
+		t.Error("duplicate Create succeeded")
+	}
+
+	if n := len(bus.Events()); n != 0 {
+		t.Errorf("events = %v, want none", bus.Names())
+	}
+	if tp := topics(t, db); len(tp) != 1 {
+		t.Errorf("outbox topics = %v, want only the first create", tp)
+	}
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/jobs"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	JobDeliver = "webhook.deliver"
+
+	maxResponse = 1 << 10 // bytes of response body kept in the log
+)
+
+type deliverPayload struct {
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+}
+
+// Deliverer is the jobs.Handler for JobDeliver. A failed attempt returns an
+// error so the job is retried with backoff; after MaxFailures consecutive
+// failures the subscription is disabled and pending retries are dropped.
+type Deliverer struct {
+	DB          *gorm.DB
+	Client      *http.Client
+	UserAgent   string
+	MaxFailures int
+	Log         *zap.Logger
+}
+
+func NewDeliverer(db *gorm.DB, cfg *config.Config, log *zap.Logger) *Deliverer {
+	return &Deliverer{
+		DB:          db,
+		Client:      &http.Client{Timeout: cfg.WebhookTimeout, Transport: newTransport(cfg.WebhookAllowPrivate)},
+		UserAgent:   cfg.AppName + "-webhooks",
+		MaxFailures: cfg.WebhookMaxFailures,
+		Log:         log.Named("webhook"),
+	}
+}
+
+func (d *Deliverer) Type() string { return JobDeliver }
+
+func (d *Deliverer) Handle(ctx context.Context, job *jobs.Job) error {
+	var p deliverPayload
+	if err := job.Bind(&p); err != nil {
+		return err
+	}
+	var sub Subscription
+	err := d.DB.WithContext(ctx).First(&sub, "id = ?", p.SubscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // deleted meanwhile
+	}
+	if err != nil {
+		return err
+	}
+	if !sub.Active {
+		d.Log.Debug("subscription disabled, dropping delivery",
+			zap.String("subscription", sub.ID.String()),
+			zap.String("event_id", p.EventID.String()),
+		)
+		return nil
+	}
+
+	del := d.send(ctx, &sub, p, job.Attempt+1)
+	if err := d.DB.WithContext(ctx).Create(del).Error; err != nil {
+		d.Log.Error("record delivery failed", zap.String("subscription", sub.ID.String()), zap.Error(err))
+	}
+	if del.Success {
+		if sub.FailureCount > 0 {
+			return d.DB.WithContext(ctx).Model(&sub).Update("failure_count", 0).Error
+		}
+		return nil
+	}
+
+	if err := d.recordFailure(ctx, &sub); err != nil {
+		d.Log.Error("update failure count failed", zap.String("subscription", sub.ID.String()), zap.Error(err))
+	}
+	if del.Error != "" {
+		return fmt.Errorf("webhook: %s", del.Error)
+	}
+	return fmt.Errorf("webhook: %s responded %d", sub.URL, del.StatusCode)
+}
+
+// send POSTs the signed body once and returns the log entry (not yet stored).
+func (d *Deliverer) send(ctx context.Context, sub *Subscription, p deliverPayload, attempt int) *Delivery {
+	now := time.Now()
+	del := &Delivery{
+		ID:             uuid.New(),
+		SubscriptionID: sub.ID,
+		EventID:        p.EventID,
+		EventType:      p.EventType,
+		Request:        p.Body,
+		Attempt:        attempt,
+		CreatedAt:      now,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(p.Body))
+	if err != nil {
+		del.Error = err.Error()
+		return del
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", d.UserAgent)
+	req.Header.Set(HeaderID, p.EventID.String())
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(now.Unix()))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, p.Body))
+
+	resp, err := d.Client.Do(req)
+	del.DurationMS = time.Since(now).Milliseconds()
+	if err != nil {
+		del.Error = err.Error()
+		return del
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	del.StatusCode = resp.StatusCode
+	del.Response = string(body)
+	del.Success = resp.StatusCode >= 200 && resp.StatusCode <= 299
+	return del
+}
+
+func (d *Deliverer) recordFailure(ctx context.Context, sub *Subscription) error {
+	limit := d.MaxFailures
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	var row struct {
+		FailureCount int
+		Active       bool
+	}
+	err := d.DB.WithContext(ctx).Raw(`
+		UPDATE webhook_subscriptions
+		SET failure_count = failure_count + 1,
+		    active = active AND failure_count + 1 < ?,
+		    disabled_at = CASE WHEN active AND failure_count + 1 >= ? THEN NOW() ELSE disabled_at END,
+		    updated_at = NOW()
+		WHERE id = ?
+		RETURNING failure_count, active`,
+		limit, limit, sub.ID,
+	).Scan(&row).Error
+	if err != nil {
+		return err
+	}
+	if !row.Active && sub.Active {
+		d.Log.Warn("webhook subscription disabled after repeated failures",
+			zap.String("subscription", sub.ID.String()),
+			zap.String("url", sub.URL),
+			zap.Int("failures", row.FailureCount),
+		)
+	}
+	return nil
+}
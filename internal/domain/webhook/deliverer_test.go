@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/domain/user"
+	"microseed/internal/jobs"
+	"microseed/internal/outbox"
+	"microseed/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.SQLite(t)
+	if err := db.AutoMigrate(&Subscription{}, &Delivery{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestSubscription(t *testing.T, db *gorm.DB, url string) *Subscription {
+	t.Helper()
+	now := time.Now()
+	sub := &Subscription{
+		ID:        uuid.New(),
+		URL:       url,
+		Secret:    newSecret(),
+		Events:    []string{"*"},
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := db.Create(sub).Error; err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+// newTestDeliverer allows loopback targets so httptest receivers work.
+func newTestDeliverer(db *gorm.DB, maxFailures int) *Deliverer {
+	return NewDeliverer(db, &config.Config{
+		AppName:             "microseed",
+		WebhookTimeout:      5 * time.Second,
+		WebhookMaxFailures:  maxFailures,
+		WebhookAllowPrivate: true,
+	}, zap.NewNop())
+}
+
+func deliverJob(t *testing.T, sub *Subscription, eventID uuid.UUID, body string) *jobs.Job {
+	t.Helper()
+	raw, err := json.Marshal(deliverPayload{
+		SubscriptionID: sub.ID,
+		EventID:        eventID,
+		EventType:      "user.created",
+		Body:           json.RawMessage(body),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &jobs.Job{ID: uuid.NewString(), Type: JobDeliver, Payload: raw}
+}
+
+func TestDelivererSignsAndLogs(t *testing.T) {
+	db := newTestDB(t)
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Clone(), b}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	sub := newTestSubscription(t, db, srv.URL)
+	eventID := uuid.New()
+	body := `{"id":"e1","type":"user.created"}`
+	if err := newTestDeliverer(db, 3).Handle(context.Background(), deliverJob(t, sub, eventID, body)); err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-got
+	if string(r.body) != body {
+		t.Errorf("body = %s", r.body)
+	}
+	if r.header.Get(HeaderID) != eventID.String() {
+		t.Errorf("%s = %q", HeaderID, r.header.Get(HeaderID))
+	}
+	if err := Verify(sub.Secret, r.header.Get(HeaderSignature), r.body, time.Minute); err != nil {
+		t.Errorf("signature: %v", err)
+	}
+
+	var logged []Delivery
+	if err := db.Find(&logged).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || !logged[0].Success || logged[0].StatusCode != 200 || logged[0].Response != "ok" || logged[0].Attempt != 1 {
+		t.Errorf("delivery log = %+v", logged)
+	}
+}
+
+func TestDelivererDisablesAfterMaxFailures(t *testing.T) {
+	db := newTestDB(t)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	sub := newTestSubscription(t, db, srv.URL)
+	d := newTestDeliverer(db, 2)
+	ctx := context.Background()
+	for range 2 {
+		if err := d.Handle(ctx, deliverJob(t, sub, uuid.New(), `{}`)); err == nil {
+			t.Fatal("failed delivery returned nil")
+		}
+	}
+	var reloaded Subscription
+	if err := db.First(&reloaded, "id = ?", sub.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Active || reloaded.FailureCount != 2 || reloaded.DisabledAt == nil {
+		t.Fatalf("subscription = %+v, want disabled after 2 failures", reloaded)
+	}
+
+	// disabled: later deliveries are dropped without a request
+	if err := d.Handle(ctx, deliverJob(t, sub, uuid.New(), `{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("receiver hit %d times, want 2", n)
+	}
+}
+
+func TestDelivererRefusesPrivateAddresses(t *testing.T) {
+	db := newTestDB(t)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	// stored directly, as if DNS changed after registration
+	sub := newTestSubscription(t, db, srv.URL)
+	d := NewDeliverer(db, &config.Config{WebhookTimeout: 5 * time.Second}, zap.NewNop())
+	err := d.Handle(context.Background(), deliverJob(t, sub, uuid.New(), `{}`))
+	if err == nil || !strings.Contains(err.Error(), "address not allowed") {
+		t.Errorf("err = %v, want address not allowed", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("receiver hit %d times", n)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	cases := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://93.184.215.14/hook", true},
+		{"http://[2606:4700::1111]/hook", true},
+		{"ftp://93.184.215.14/", false},
+		{"/relative", false},
+		{"http://127.0.0.1:8080/", false},
+		{"http://localhost/", false},
+		{"http://10.1.2.3/", false},
+		{"http://172.16.0.1/", false},
+		{"http://192.168.1.1/", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/", false},
+		{"http://0.0.0.0/", false},
+		{"http://[::1]/", false},
+		{"http://[fe80::1]/", false},
+		{"http://[fd00::1]/", false},
+		{"http://[::ffff:127.0.0.1]/", false},
+		{"http://[64:ff9b::a9fe:a9fe]/", false},
+	}
+	for _, tc := range cases {
+		err := checkURL(context.Background(), tc.url)
+		if (err == nil) != tc.ok {
+			t.Errorf("checkURL(%q) = %v, want ok=%v", tc.url, err, tc.ok)
+		}
+	}
+}
+
+func TestRoutesRequireAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t)
+	svc := &serviceImpl{DB: db}
+	r := gin.New()
+	(&Handler{Svc: svc, Token: "s3cret"}).Register(r)
+
+	for _, tc := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer nope", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/webhooks", nil)
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("Authorization %q: status %d, want %d", tc.auth, w.Code, tc.want)
+		}
+	}
+}
+
+func TestCreateRejectsPrivateURL(t *testing.T) {
+	svc := &serviceImpl{DB: newTestDB(t)}
+	_, err := svc.Create(context.Background(), Input{URL: "http://169.254.169.254/", Events: []string{"*"}})
+	var ae *apperr.Error
+	if !errors.As(err, &ae) || ae.Kind != apperr.KindValidation {
+		t.Fatalf("Create err = %v, want a validation error", err)
+	}
+	var n int64
+	svc.DB.Model(&Subscription{}).Count(&n)
+	if n != 0 {
+		t.Errorf("%d subscriptions stored", n)
+	}
+}
+
+func TestDispatchFromOutboxIsIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	sub := newTestSubscription(t, db, "https://example.com/hook")
+	q := jobs.NewMemoryQueue()
+	svc := NewService(db, jobs.NewClient(q, &config.Config{}), &config.Config{WebhookQueue: jobs.DefaultQueue})
+	subs := NewSubscribers(svc)
+	if len(subs) != 1 {
+		t.Fatalf("subscribers = %d", len(subs))
+	}
+
+	ctx := context.Background()
+	msg := &outbox.Message{
+		ID:        uuid.New(),
+		Topic:     user.TopicCreated,
+		Payload:   json.RawMessage(`{"id":"u1"}`),
+		CreatedAt: time.Now(),
+	}
+	// a relay retry hands the same message over again
+	for range 2 {
+		if err := subs[0].Handle(ctx, outbox.Event{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := &outbox.Message{ID: uuid.New(), Topic: "internal.audit", Payload: json.RawMessage(`{}`)}
+	if err := subs[0].Handle(ctx, outbox.Event{Message: other}); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := q.Pop(ctx, jobs.DefaultQueue, 100*time.Millisecond)
+	if err != nil || job == nil {
+		t.Fatalf("pop: %v %v", job, err)
+	}
+	var p deliverPayload
+	if err := job.Bind(&p); err != nil {
+		t.Fatal(err)
+	}
+	var env Envelope
+	if err := json.Unmarshal(p.Body, &env); err != nil {
+		t.Fatal(err)
+	}
+	if p.SubscriptionID != sub.ID || p.EventID != msg.ID || env.ID != msg.ID || env.Type != user.TopicCreated || string(env.Data) != `{"id":"u1"}` {
+		t.Errorf("payload = %+v, envelope = %+v", p, env)
+	}
+	if job, _ := q.Pop(ctx, jobs.DefaultQueue, 50*time.Millisecond); job != nil {
+		t.Errorf("unexpected second job %s", job.Payload)
+	}
+}
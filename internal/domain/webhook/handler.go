@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/httpx"
+	"microseed/internal/idempotency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	Svc   Service
+	Idem  *idempotency.Middleware
+	Token string
+}
+
+func NewHandler(svc Service, idem *idempotency.Middleware, cfg *config.Config) *Handler {
+	return &Handler{Svc: svc, Idem: idem, Token: cfg.AdminToken}
+}
+
+// Register mounts the subscription API behind the admin token: it holds
+// signing secrets and makes the server send requests to arbitrary URLs.
+func (h *Handler) Register(r *gin.Engine) {
+	g := r.Group("/v1/webhooks", httpx.AdminAuth(h.Token))
+	g.GET("", h.list)
+	g.POST("", h.Idem.Handle, h.create)
+	g.GET("/:id", h.get)
+	g.PUT("/:id", h.update)
+	g.DELETE("/:id", h.delete)
+	g.GET("/:id/deliveries", h.deliveries)
+	g.POST("/:id/deliveries/:delivery_id/redeliver", h.redeliver)
+}
+
+type subscriptionRequest struct {
+	URL         string   `json:"url" binding:"required,http_url"`
+	Events      []string `json:"events" binding:"required,min=1,dive,required"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+func (r subscriptionRequest) input() Input {
+	return Input{URL: r.URL, Events: r.Events, Description: r.Description, Active: r.Active}
+}
+
+func (h *Handler) list(c *gin.Context) {
+	subs, err := h.Svc.List(c.Request.Context())
+	if err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+func (h *Handler) create(c *gin.Context) {
+	var req subscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Fail(c, apperr.Invalid(err))
+		return
+	}
+	sub, err := h.Svc.Create(c.Request.Context(), req.input())
+	if err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	// the secret is only returned on creation
+	c.JSON(http.StatusCreated, struct {
+		*Subscription
+		Secret string `json:"secret"`
+	}{sub, sub.Secret})
+}
+
+func (h *Handler) get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid id"))
+		return
+	}
+	sub, err := h.Svc.Get(c.Request.Context(), id)
+	if err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *Handler) update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid id"))
+		return
+	}
+	var req subscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httpx.Fail(c, apperr.Invalid(err))
+		return
+	}
+	sub, err := h.Svc.Update(c.Request.Context(), id, req.input())
+	if err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *Handler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid id"))
+		return
+	}
+	if err := h.Svc.Delete(c.Request.Context(), id); err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) deliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid id"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	out, err := h.Svc.Deliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": out})
+}
+
+func (h *Handler) redeliver(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid id"))
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid delivery id"))
+		return
+	}
+	if err := h.Svc.Redeliver(c.Request.Context(), id, deliveryID); err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+}
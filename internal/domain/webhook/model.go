@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Subscription struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	URL          string     `gorm:"not null" json:"url"`
+	Secret       string     `gorm:"not null" json:"-"`
+	Events       []string   `gorm:"type:jsonb;serializer:json;not null" json:"events"`
+	Description  string     `gorm:"not null;default:''" json:"description,omitempty"`
+	Active       bool       `gorm:"not null" json:"active"`
+	FailureCount int        `gorm:"not null;default:0" json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+func (Subscription) TableName() string { return "webhook_subscriptions" }
+
+// Matches reports whether the subscription wants eventType. Filters are
+// exact names ("user.created"), prefixes ("user.*") or "*".
+func (s *Subscription) Matches(eventType string) bool {
+	for _, f := range s.Events {
+		if f == "*" || f == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(f, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one attempt to deliver an event to a subscription.
+type Delivery struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	SubscriptionID uuid.UUID       `gorm:"type:uuid;not null" json:"subscription_id"`
+	EventID        uuid.UUID       `gorm:"type:uuid;not null" json:"event_id"`
+	EventType      string          `gorm:"not null" json:"event_type"`
+	Request        json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"request"`
+	Attempt        int             `gorm:"not null" json:"attempt"`
+	StatusCode     int             `gorm:"not null;default:0" json:"status_code"`
+	Response       string          `gorm:"not null;default:''" json:"response,omitempty"`
+	Error          string          `gorm:"not null;default:''" json:"error,omitempty"`
+	Success        bool            `gorm:"not null" json:"success"`
+	DurationMS     int64           `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt      time.Time       `gorm:"not null" json:"created_at"`
+}
+
+func (Delivery) TableName() string { return "webhook_deliveries" }
+
+// Envelope is the JSON body POSTed to subscribers.
+type Envelope struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"microseed/internal/httpx"
+	"microseed/internal/jobs"
+
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	fx.Provide(NewService, NewDeliverer),
+	fx.Provide(
+		fx.Annotate(
+			NewHandler,
+			fx.As(new(httpx.RouteRegistrar)),
+			fx.ResultTags(`group:"routes"`),
+		),
+		fx.Annotate(
+			func(d *Deliverer) jobs.Handler { return d },
+			fx.ResultTags(`group:"jobs"`),
+		),
+		fx.Annotate(
+			NewSubscribers,
+			fx.ResultTags(`group:"events,flatten"`),
+		),
+	),
+)
@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/jobs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrDisabled = apperr.Conflict("webhook subscription is disabled")
+
+type Input struct {
+	URL         string
+	Events      []string
+	Description string
+	Active      *bool // Update only; re-enabling resets the failure count
+}
+
+type Service interface {
+	Create(ctx context.Context, in Input) (*Subscription, error)
+	List(ctx context.Context) ([]Subscription, error)
+	Get(ctx context.Context, id uuid.UUID) (*Subscription, error)
+	Update(ctx context.Context, id uuid.UUID, in Input) (*Subscription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Deliveries(ctx context.Context, id uuid.UUID, limit int) ([]Delivery, error)
+	Redeliver(ctx context.Context, id, deliveryID uuid.UUID) error
+	// Dispatch enqueues one delivery of env per active subscription matching
+	// env.Type. env.ID must be the same on every retry of the event: it is
+	// the Webhook-Id receivers dedupe on and keys the jobs as unique.
+	Dispatch(ctx context.Context, env Envelope) (int, error)
+}
+
+type serviceImpl struct {
+	DB           *gorm.DB
+	Jobs         *jobs.Client
+	Queue        string
+	MaxRetry     int
+	AllowPrivate bool
+}
+
+func NewService(db *gorm.DB, jc *jobs.Client, cfg *config.Config) Service {
+	return &serviceImpl{
+		DB:           db,
+		Jobs:         jc,
+		Queue:        cfg.WebhookQueue,
+		MaxRetry:     cfg.WebhookMaxRetry,
+		AllowPrivate: cfg.WebhookAllowPrivate,
+	}
+}
+
+func (s *serviceImpl) checkURL(ctx context.Context, raw string) error {
+	if s.AllowPrivate {
+		return nil
+	}
+	return checkURL(ctx, raw)
+}
+
+func (s *serviceImpl) Create(ctx context.Context, in Input) (*Subscription, error) {
+	if err := s.checkURL(ctx, in.URL); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sub := &Subscription{
+		ID:          uuid.New(),
+		URL:         in.URL,
+		Secret:      newSecret(),
+		Events:      in.Events,
+		Description: in.Description,
+		Active:      true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.DB.WithContext(ctx).Create(sub).Error; err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *serviceImpl) List(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := s.DB.WithContext(ctx).Order("created_at").Find(&subs).Error
+	return subs, err
+}
+
+func (s *serviceImpl) Get(ctx context.Context, id uuid.UUID) (*Subscription, error) {
+	var sub Subscription
+	if err := s.DB.WithContext(ctx).First(&sub, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *serviceImpl) Update(ctx context.Context, id uuid.UUID, in Input) (*Subscription, error) {
+	if err := s.checkURL(ctx, in.URL); err != nil {
+		return nil, err
+	}
+	var sub Subscription
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&sub, "id = ?", id).Error; err != nil {
+			return err
+		}
+		sub.URL = in.URL
+		sub.Events = in.Events
+		sub.Description = in.Description
+		sub.UpdatedAt = time.Now()
+		if in.Active != nil && *in.Active != sub.Active {
+			sub.Active = *in.Active
+			if sub.Active {
+				sub.FailureCount = 0
+				sub.DisabledAt = nil
+			} else {
+				sub.DisabledAt = &sub.UpdatedAt
+			}
+		}
+		return tx.Save(&sub).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *serviceImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	res := s.DB.WithContext(ctx).Delete(&Subscription{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *serviceImpl) Deliveries(ctx context.Context, id uuid.UUID, limit int) ([]Delivery, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	var out []Delivery
+	err := s.DB.WithContext(ctx).
+		Where("subscription_id = ?", id).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&out).Error
+	return out, err
+}
+
+// Redeliver re-sends the request body of a logged delivery with a fresh
+// signature; the event ID stays the same so receivers can dedupe.
+func (s *serviceImpl) Redeliver(ctx context.Context, id, deliveryID uuid.UUID) error {
+	sub, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !sub.Active {
+		return ErrDisabled
+	}
+	var d Delivery
+	if err := s.DB.WithContext(ctx).First(&d, "id = ? AND subscription_id = ?", deliveryID, id).Error; err != nil {
+		return err
+	}
+	return s.enqueue(ctx, deliverPayload{
+		SubscriptionID: id,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Body:           d.Request,
+	})
+}
+
+func (s *serviceImpl) Dispatch(ctx context.Context, env Envelope) (int, error) {
+	var subs []Subscription
+	if err := s.DB.WithContext(ctx).Where("active").Find(&subs).Error; err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for i := range subs {
+		if !subs[i].Matches(env.Type) {
+			continue
+		}
+		err := s.enqueue(ctx, deliverPayload{
+			SubscriptionID: subs[i].ID,
+			EventID:        env.ID,
+			EventType:      env.Type,
+			Body:           body,
+		}, jobs.Unique(subs[i].ID.String()+":"+env.ID.String(), 0))
+		if errors.Is(err, jobs.ErrDuplicate) {
+			continue // enqueued by an earlier attempt
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (s *serviceImpl) enqueue(ctx context.Context, p deliverPayload, opts ...jobs.Option) error {
+	opts = append([]jobs.Option{jobs.OnQueue(s.Queue), jobs.MaxRetry(s.MaxRetry)}, opts...)
+	_, err := s.Jobs.Enqueue(ctx, JobDeliver, p, opts...)
+	return err
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"microseed/internal/apperr"
+	"microseed/internal/httpx"
+)
+
+// errForbiddenAddress is returned when a webhook URL points (or, at dial
+// time, resolves) to a non-public address: loopback, private ranges,
+// link-local (cloud metadata at 169.254.169.254) and the like.
+var errForbiddenAddress = errors.New("webhook: address not allowed")
+
+// not covered by netip's IsPrivate / IsLoopback / IsLinkLocal*
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64 can reach any IPv4
+	netip.MustParsePrefix("2002::/16"),     // 6to4, same
+}
+
+func publicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURL rejects subscription URLs that are not http(s) or whose host
+// resolves to a non-public address. The dialer checks again on every
+// request, since DNS answers can change after registration.
+func checkURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return apperr.Validation("url must be an absolute http(s) URL")
+	}
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !publicIP(ip) {
+			return apperr.Validation("url must point to a public address")
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return apperr.Validation("url host does not resolve")
+	}
+	for _, ip := range addrs {
+		if !publicIP(ip) {
+			return apperr.Validation("url must point to a public address")
+		}
+	}
+	return nil
+}
+
+// newTransport dials public addresses only, unless allowPrivate is set
+// (local development). Proxies are not used: they would hide the target
+// address from the check.
+func newTransport(allowPrivate bool) http.RoundTripper {
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		d.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !publicIP(ip) {
+				return fmt.Errorf("%w: %s", errForbiddenAddress, host)
+			}
+			return nil
+		}
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = d.DialContext
+	return httpx.Transport(t)
+}
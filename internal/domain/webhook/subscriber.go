@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"context"
+
+	"microseed/internal/domain/user"
+	"microseed/internal/events"
+	"microseed/internal/outbox"
+)
+
+// topics are the outbox topics forwarded to subscriptions.
+var topics = map[string]bool{
+	user.TopicCreated: true,
+	user.TopicUpdated: true,
+	user.TopicDeleted: true,
+}
+
+// NewSubscribers forwards user lifecycle events relayed from the outbox
+// (OUTBOX_PUBLISHER=local). The outbox message ID is the event ID, and the
+// subscriber is sync, so a failed Dispatch leaves the message for the relay
+// to retry.
+func NewSubscribers(svc Service) []events.Subscriber {
+	return []events.Subscriber{
+		events.On("webhook.dispatch", func(ctx context.Context, evt outbox.Event) error {
+			if !topics[evt.Topic] {
+				return nil
+			}
+			_, err := svc.Dispatch(ctx, Envelope{
+				ID:        evt.ID,
+				Type:      evt.Topic,
+				CreatedAt: evt.CreatedAt,
+				Data:      evt.Payload,
+			})
+			return err
+		}),
+	}
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"microseed/pkg/backoff"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var ErrClosed = errors.New("events: bus closed")
+
+// LocalBus dispatches events in-process. Each subscriber is isolated: a
+// failure or panic is retried and logged without affecting the others.
+type LocalBus struct {
+	log    *zap.Logger
+	tracer trace.Tracer
+
+	mu     sync.RWMutex
+	subs   map[string][]Subscriber
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewLocalBus(log *zap.Logger) *LocalBus {
+	return &LocalBus{
+		log:    log.Named("events"),
+		tracer: otel.Tracer("microseed/events"),
+		subs:   map[string][]Subscriber{},
+	}
+}
+
+func (b *LocalBus) Subscribe(s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[s.Event()] = append(b.subs[s.Event()], s)
+}
+
+// Publish runs sync subscribers inline and returns their joined errors;
+// async subscribers run in the background and only log failures.
+func (b *LocalBus) Publish(ctx context.Context, evt Event) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	subs := b.subs[evt.EventName()]
+	b.wg.Add(len(subs))
+	b.mu.RUnlock()
+
+	ctx, span := b.tracer.Start(ctx, "publish "+evt.EventName(),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("event.name", evt.EventName())),
+	)
+	defer span.End()
+
+	var errs []error
+	for _, s := range subs {
+		if s.Options().Async {
+			go func() {
+				defer b.wg.Done()
+				b.deliverAsync(ctx, s, evt)
+			}()
+			continue
+		}
+		errs = append(errs, b.deliver(ctx, s, evt))
+		b.wg.Done()
+	}
+	err := errors.Join(errs...)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (b *LocalBus) deliverAsync(parent context.Context, s Subscriber, evt Event) {
+	// new trace linked to the publisher; keeps context values, drops cancellation
+	ctx, span := b.tracer.Start(context.WithoutCancel(parent), "async "+evt.EventName(),
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(parent)),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+	)
+	defer span.End()
+	_ = b.deliver(ctx, s, evt)
+}
+
+func (b *LocalBus) deliver(ctx context.Context, s Subscriber, evt Event) error {
+	opts := s.Options()
+	lg := b.log.With(zap.String("event", evt.EventName()), zap.String("subscriber", s.Name()))
+
+	var err error
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff.Exponential(attempt, opts.Backoff, 30*time.Second)):
+			}
+		}
+		err = b.handle(ctx, s, evt, attempt)
+		if err == nil {
+			return nil
+		}
+		lg.Warn("subscriber failed", zap.Int("attempt", attempt), zap.Error(err))
+	}
+	lg.Error("subscriber gave up", zap.Int("retries", opts.Retries), zap.Error(err))
+	return fmt.Errorf("events: %s: %w", s.Name(), err)
+}
+
+func (b *LocalBus) handle(ctx context.Context, s Subscriber, evt Event, attempt int) (err error) {
+	ctx, span := b.tracer.Start(ctx, "handle "+evt.EventName(), trace.WithAttributes(
+		attribute.String("event.name", evt.EventName()),
+		attribute.String("event.subscriber", s.Name()),
+		attribute.Int("event.attempt", attempt),
+	))
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	return s.Handle(ctx, evt)
+}
+
+// Close rejects new events and waits for async subscribers to finish.
+func (b *LocalBus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("events: drain interrupted: %w", ctx.Err())
+	}
+}
+
+type subscribersIn struct {
+	fx.In
+
+	Bus         *LocalBus
+	Subscribers []Subscriber `group:"events"`
+}
+
+// RegisterSubscribers attaches the fx group after construction, so
+// subscribers may depend on services that publish on the bus.
+func RegisterSubscribers(in subscribersIn) {
+	for _, s := range in.Subscribers {
+		in.Bus.Subscribe(s)
+	}
+}
+
+func RegisterHooks(lc fx.Lifecycle, b *LocalBus, log *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Info("draining event bus")
+			return b.Close(ctx)
+		},
+	})
+}
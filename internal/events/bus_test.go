@@ -0,0 +1,218 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type testEvent struct{ ID int }
+
+func (testEvent) EventName() string { return "test.event" }
+
+type otherEvent struct{}
+
+func (otherEvent) EventName() string { return "test.other" }
+
+func TestLocalBusSyncReturnsErrors(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	var got []int
+	b.Subscribe(On("ok", func(_ context.Context, e testEvent) error {
+		got = append(got, e.ID)
+		return nil
+	}))
+	b.Subscribe(On("fail", func(context.Context, testEvent) error {
+		return errors.New("boom")
+	}))
+	b.Subscribe(On("other", func(context.Context, otherEvent) error {
+		t.Error("subscriber for another event was called")
+		return nil
+	}))
+
+	err := b.Publish(context.Background(), testEvent{ID: 7})
+	if err == nil || !strings.Contains(err.Error(), "fail") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want the failing subscriber's error", err)
+	}
+	if !reflect.DeepEqual(got, []int{7}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestLocalBusAsyncDetachedAndDrainedOnClose(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	release := make(chan struct{})
+	var done, canceled atomic.Bool
+	b.Subscribe(On("slow", func(ctx context.Context, _ testEvent) error {
+		<-release
+		canceled.Store(ctx.Err() != nil)
+		done.Store(true)
+		return errors.New("ignored")
+	}, Async()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := b.Publish(ctx, testEvent{}); err != nil {
+		t.Fatalf("async failures must not reach the publisher: %v", err)
+	}
+	cancel()
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close(context.Background()) }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the async subscriber finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if !done.Load() {
+		t.Fatal("async subscriber did not run")
+	}
+	if canceled.Load() {
+		t.Fatal("async subscriber inherited the publisher's cancellation")
+	}
+}
+
+func TestLocalBusCloseRejectsPublish(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(context.Background(), testEvent{}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("err = %v, want ErrClosed", err)
+	}
+}
+
+func TestLocalBusCloseHonoursDeadline(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	release := make(chan struct{})
+	defer close(release)
+	b.Subscribe(On("stuck", func(context.Context, testEvent) error {
+		<-release
+		return nil
+	}, Async()))
+	if err := b.Publish(context.Background(), testEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestLocalBusRetries(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	var calls atomic.Int32
+	b.Subscribe(On("flaky", func(context.Context, testEvent) error {
+		if calls.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, Retries(2, time.Millisecond)))
+
+	if err := b.Publish(context.Background(), testEvent{}); err != nil {
+		t.Fatalf("err = %v, want success on the last retry", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("calls = %d, want 3", n)
+	}
+}
+
+func TestLocalBusRetriesExhausted(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	var calls atomic.Int32
+	b.Subscribe(On("broken", func(context.Context, testEvent) error {
+		calls.Add(1)
+		return errors.New("down")
+	}, Retries(1, time.Millisecond)))
+
+	if err := b.Publish(context.Background(), testEvent{}); err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls = %d, want 2", n)
+	}
+}
+
+func TestLocalBusRetryStopsOnCancel(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	var calls atomic.Int32
+	b.Subscribe(On("broken", func(context.Context, testEvent) error {
+		calls.Add(1)
+		return errors.New("down")
+	}, Retries(5, time.Hour)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Publish(ctx, testEvent{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("calls = %d, want 1", n)
+	}
+}
+
+func TestLocalBusPanicIsolated(t *testing.T) {
+	b := NewLocalBus(zap.NewNop())
+	var after, async atomic.Bool
+	b.Subscribe(On("panics", func(context.Context, testEvent) error {
+		panic("kaboom")
+	}))
+	b.Subscribe(On("after", func(context.Context, testEvent) error {
+		after.Store(true)
+		return nil
+	}))
+	b.Subscribe(On("async-panics", func(context.Context, testEvent) error {
+		panic("kaboom")
+	}, Async()))
+	b.Subscribe(On("async", func(context.Context, testEvent) error {
+		async.Store(true)
+		return nil
+	}, Async()))
+
+	err := b.Publish(context.Background(), testEvent{})
+	if err == nil || !strings.Contains(err.Error(), "panic: kaboom") {
+		t.Fatalf("err = %v, want the recovered panic", err)
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !after.Load() || !async.Load() {
+		t.Fatalf("after=%v async=%v, want both subscribers to run", after.Load(), async.Load())
+	}
+}
+
+func TestRecordingBus(t *testing.T) {
+	b := NewRecordingBus()
+	ctx := context.Background()
+	_ = b.Publish(ctx, testEvent{ID: 1})
+	_ = b.Publish(ctx, otherEvent{})
+	_ = b.Publish(ctx, testEvent{ID: 2})
+
+	if got := b.Names(); !reflect.DeepEqual(got, []string{"test.event", "test.other", "test.event"}) {
+		t.Fatalf("names = %v", got)
+	}
+	if got := Recorded[testEvent](b); !reflect.DeepEqual(got, []testEvent{{ID: 1}, {ID: 2}}) {
+		t.Fatalf("recorded = %v", got)
+	}
+
+	evts := b.Events()
+	evts[0] = otherEvent{}
+	if _, ok := b.Events()[0].(testEvent); !ok {
+		t.Fatal("Events must return a copy")
+	}
+
+	b.Reset()
+	if n := len(b.Events()); n != 0 {
+		t.Fatalf("events after reset = %d", n)
+	}
+}
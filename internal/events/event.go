@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// Event is a typed domain event; EventName must not depend on field values.
+type Event interface {
+	EventName() string
+}
+
+type Bus interface {
+	Publish(ctx context.Context, evt Event) error
+}
+
+type Subscriber interface {
+	Name() string
+	Event() string
+	Options() Options
+	Handle(ctx context.Context, evt Event) error
+}
+
+type Options struct {
+	Async   bool // run in a goroutine, detached from the publisher's cancellation
+	Retries int  // extra attempts after the first failure
+	Backoff time.Duration
+}
+
+type Option func(*Options)
+
+func Async() Option {
+	return func(o *Options) { o.Async = true }
+}
+
+func Retries(n int, backoff time.Duration) Option {
+	return func(o *Options) {
+		o.Retries = n
+		o.Backoff = backoff
+	}
+}
+
+type subscriber[T Event] struct {
+	name string
+	opts Options
+	fn   func(ctx context.Context, evt T) error
+}
+
+func (s subscriber[T]) Name() string     { return s.name }
+func (s subscriber[T]) Options() Options { return s.opts }
+
+func (s subscriber[T]) Event() string {
+	var zero T
+	return zero.EventName()
+}
+
+func (s subscriber[T]) Handle(ctx context.Context, evt Event) error {
+	return s.fn(ctx, evt.(T))
+}
+
+// On builds a Subscriber for events of type T.
+func On[T Event](name string, fn func(ctx context.Context, evt T) error, opts ...Option) Subscriber {
+	o := Options{Backoff: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return subscriber[T]{name: name, opts: o, fn: fn}
+}
@@ -0,0 +1,13 @@
+package events
+
+import "go.uber.org/fx"
+
+// Module provides the in-process Bus. Subscribers are contributed with
+// fx.ResultTags(`group:"events"`), e.g. events.On[user.Created](...).
+var Module = fx.Options(
+	fx.Provide(
+		NewLocalBus,
+		func(b *LocalBus) Bus { return b },
+	),
+	fx.Invoke(RegisterSubscribers, RegisterHooks),
+)
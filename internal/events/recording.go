@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// RecordingBus stores published events instead of dispatching them (tests).
+type RecordingBus struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewRecordingBus() *RecordingBus {
+	return &RecordingBus{}
+}
+
+func (b *RecordingBus) Publish(_ context.Context, evt Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, evt)
+	return nil
+}
+
+func (b *RecordingBus) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.events...)
+}
+
+func (b *RecordingBus) Names() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]string, len(b.events))
+	for i, e := range b.events {
+		out[i] = e.EventName()
+	}
+	return out
+}
+
+func (b *RecordingBus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = nil
+}
+
+// Recorded returns the published events of type T, in order.
+func Recorded[T Event](b *RecordingBus) []T {
+	var out []T
+	for _, e := range b.Events() {
+		if t, ok := e.(T); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
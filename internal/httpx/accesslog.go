@@ -0,0 +1,137 @@
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+
+	applog "microseed/internal/log"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AccessLogOptions struct {
+	SkipPaths    []string // exact paths (health probes), never logged
+	SampleRate   float64  // fraction of < 400 responses logged; 4xx/5xx always are
+	BodyMaxBytes int      // request/response body capture at debug level (0 = off)
+	Redactor     *Redactor
+}
+
+// AccessLog writes one http_request line per request through the request
+// logger. The route template (c.FullPath) is logged instead of the raw
+// path; the level follows the status (5xx error, 4xx warn, else info).
+// With debug enabled, redacted headers, query and bodies are added.
+func AccessLog(opts AccessLogOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(opts.SkipPaths, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		lg := applog.FromContext(c.Request.Context())
+		debug := opts.BodyMaxBytes > 0 && lg.Core().Enabled(zap.DebugLevel)
+
+		var reqBody []byte
+		var reqTruncated bool
+		var w *bodyWriter
+		if debug {
+			reqBody, reqTruncated = peekBody(c, opts.BodyMaxBytes)
+			w = &bodyWriter{ResponseWriter: c.Writer, max: opts.BodyMaxBytes}
+			c.Writer = w
+		}
+
+		// deferred, so a request that panics still gets its line
+		panicked := true
+		defer func() {
+			status := c.Writer.Status()
+			if panicked && !c.Writer.Written() {
+				status = http.StatusInternalServerError // what Recovery will answer
+			}
+			if status < 400 && opts.SampleRate < 1 && rand.Float64() >= opts.SampleRate {
+				return
+			}
+			// re-read: auth middleware may have added user_id
+			lg = applog.FromContext(c.Request.Context())
+			fields := []zap.Field{
+				zap.String("method", c.Request.Method),
+				zap.Int("status", status),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("ip", c.ClientIP()),
+				zap.String("user_agent", c.Request.UserAgent()),
+				zap.Int64("request_bytes", max(c.Request.ContentLength, 0)),
+				zap.Int("response_bytes", max(c.Writer.Size(), 0)),
+			}
+			if c.FullPath() == "" {
+				// no matching route, so there is no template to log
+				fields = append(fields, zap.String("path", c.Request.URL.Path))
+			}
+			if debug {
+				fields = append(fields,
+					zap.String("query", opts.Redactor.Query(c.Request.URL.Query())),
+					zap.Any("request_headers", opts.Redactor.Headers(c.Request.Header)),
+					zap.String("request_body", opts.Redactor.Body(c.ContentType(), reqBody, reqTruncated)),
+					zap.String("response_body", opts.Redactor.Body(w.Header().Get("Content-Type"), w.body.Bytes(), w.truncated)),
+				)
+			}
+
+			switch {
+			case status >= 500:
+				// the cause is logged separately (Errors, Recovery)
+				lg.WithOptions(zap.AddStacktrace(zap.FatalLevel)).Error("http_request", fields...)
+			case status >= 400:
+				lg.Warn("http_request", fields...)
+			default:
+				lg.Info("http_request", fields...)
+			}
+		}()
+		c.Next()
+		panicked = false
+	}
+}
+
+// peekBody reads up to n bytes of the request body without consuming it.
+func peekBody(c *gin.Context, n int) ([]byte, bool) {
+	if c.Request.Body == nil {
+		return nil, false
+	}
+	buf, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(n)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), c.Request.Body), c.Request.Body}
+	if len(buf) > n {
+		return buf[:n], true
+	}
+	return buf, false
+}
+
+// bodyWriter keeps the first max bytes of the response.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(b []byte) {
+	room := w.max - w.body.Len()
+	if len(b) > room {
+		b = b[:max(room, 0)]
+		w.truncated = true
+	}
+	w.body.Write(b)
+}
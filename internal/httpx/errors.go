@@ -0,0 +1,78 @@
+package httpx
+
+import (
+	"reflect"
+	"strings"
+
+	"microseed/internal/apperr"
+	applog "microseed/internal/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+func init() {
+	// validation errors report JSON field names, not Go struct fields
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// Fail writes err as application/problem+json, records it on the gin
+// context and aborts. Unknown errors become 500 "internal error"; the
+// cause is only logged (by Errors).
+func Fail(c *gin.Context, err error) {
+	e := apperr.From(err)
+	_ = c.Error(e)
+	writeProblem(c, e)
+	c.Abort()
+}
+
+// RenderErrors writes the last error added with c.Error when nothing has
+// been written yet. Middleware that stores or records the response calls
+// it after c.Next, so it sees the final status instead of an empty 200.
+func RenderErrors(c *gin.Context) {
+	if len(c.Errors) > 0 && !c.Writer.Written() {
+		writeProblem(c, apperr.From(c.Errors.Last().Err))
+	}
+}
+
+// Errors renders errors added with c.Error that no handler wrote, and logs
+// every 5xx with its cause and stack.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		RenderErrors(c)
+		e := apperr.From(c.Errors.Last().Err)
+		if e.Status() < 500 {
+			return
+		}
+		lg := applog.FromContext(c.Request.Context())
+		fields := []zap.Field{zap.String("kind", string(e.Kind)), zap.Error(e.Err)}
+		if st := e.Stack(); st != "" {
+			lg = lg.WithOptions(zap.AddStacktrace(zap.FatalLevel))
+			fields = append(fields, zap.String("stacktrace", st))
+		}
+		lg.Error("request failed", fields...)
+	}
+}
+
+func writeProblem(c *gin.Context, e *apperr.Error) {
+	p := e.Problem(c.Request.URL.Path, RequestIDFrom(c.Request.Context()))
+	c.Header("Content-Type", apperr.ProblemContentType)
+	c.JSON(p.Status, p)
+}
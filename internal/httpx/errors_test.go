@@ -0,0 +1,44 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	applog "microseed/internal/log"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestErrorsLogsOnlyServerFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.DebugLevel)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(applog.WithLogger(c.Request.Context(), zap.New(core)))
+	}, Errors())
+	r.GET("/canceled", func(c *gin.Context) { Fail(c, context.Canceled) })
+	r.GET("/boom", func(c *gin.Context) { Fail(c, errors.New("boom")) })
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		logged int
+	}{
+		{"/canceled", 499, 0},
+		{"/boom", http.StatusInternalServerError, 1},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.path, w.Code, tc.status)
+		}
+		if n := logs.FilterMessage("request failed").TakeAll(); len(n) != tc.logged {
+			t.Errorf("%s: logged %d request failed lines, want %d", tc.path, len(n), tc.logged)
+		}
+	}
+}
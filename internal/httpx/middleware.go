@@ -1,43 +1,89 @@
 package httpx
 
 import (
-	"time"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/lifecycle"
+	applog "microseed/internal/log"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
-func RequestID() gin.HandlerFunc {
+// ContextLogger stores a logger carrying the request's correlation fields
+// (request_id, trace_id, span_id, route) in the request context; any layer
+// gets it back with log.FromContext. Must run after RequestID.
+func ContextLogger(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		fields := append(applog.Fields(ctx), zap.String("route", c.FullPath()))
+		c.Request = c.Request.WithContext(applog.WithLogger(ctx, logger.With(fields...)))
+		c.Next()
+	}
+}
+
+// SetUser records the authenticated user for auth middleware: it is added
+// to the request-scoped logger as user_id and counted against rate limits
+// keyed by KeyByUser. That count may abort the request with 429, so check
+// c.IsAborted() before going on.
+func SetUser(c *gin.Context, userID string) {
+	c.Set(UserIDKey, userID)
+	ctx := c.Request.Context()
+	l := applog.FromContext(ctx).With(zap.String("user_id", userID))
+	c.Request = c.Request.WithContext(applog.WithLogger(ctx, l))
+	limitUser(c)
+}
+
+// InFlight counts requests in progress so shutdown can report them.
+func InFlight(state *lifecycle.State) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := uuid.NewString()
-		c.Writer.Header().Set("X-Request-ID", id)
-		c.Set("request_id", id)
+		defer state.Begin()()
 		c.Next()
 	}
 }
 
-func Logger(logger *zap.Logger) gin.HandlerFunc {
+// AdminAuth guards operational endpoints with a static bearer token.
+// An empty token disables them entirely.
+func AdminAuth(token string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		start := time.Now()
+		if token == "" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			Fail(c, apperr.Unauthorized(""))
+			return
+		}
 		c.Next()
-		logger.Info("http_request",
-			zap.String("method", c.Request.Method),
-			zap.String("path", c.Request.URL.Path),
-			zap.Int("status", c.Writer.Status()),
-			zap.Duration("latency", time.Since(start)),
-			zap.String("ip", c.ClientIP()),
-			zap.String("request_id", c.GetString("request_id")),
-		)
 	}
 }
 
-func Middlewares(logger *zap.Logger) []gin.HandlerFunc {
+func Middlewares(cfg *config.Config, logger *zap.Logger, state *lifecycle.State) ([]gin.HandlerFunc, error) {
+	red := NewRedactor(cfg.AccessLogRedactHeaders, cfg.AccessLogRedactFields)
+	recovery, err := Recovery(red)
+	if err != nil {
+		return nil, err
+	}
+	// otelgin first: everything after it runs inside the request span,
+	// including Recovery, so panics still end up on the span
 	return []gin.HandlerFunc{
-		gin.Recovery(),
-		RequestID(),
-		Logger(logger),
+		InFlight(state),
 		otelgin.Middleware("http-server"),
-	}
+		recovery,
+		RequestID(),
+		ContextLogger(logger),
+		AccessLog(AccessLogOptions{
+			SkipPaths:    cfg.AccessLogSkipPaths,
+			SampleRate:   cfg.AccessLogSampleRate,
+			BodyMaxBytes: cfg.AccessLogBodyMaxBytes,
+			Redactor:     red,
+		}),
+		Errors(),
+	}, nil
 }
@@ -0,0 +1,148 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"microseed/internal/apperr"
+	"microseed/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UserIDKey is the gin context key an auth middleware sets for KeyByUser.
+const UserIDKey = "user_id"
+
+// KeyFunc names the client a request counts against; "" means unknown.
+type KeyFunc func(c *gin.Context) string
+
+func KeyByIP(c *gin.Context) string { return "ip:" + c.ClientIP() }
+
+func KeyByUser(c *gin.Context) string {
+	if id := c.GetString(UserIDKey); id != "" {
+		return "user:" + id
+	}
+	return ""
+}
+
+// KeyByAPIKey uses a hash of the header value, so keys never reach Redis.
+func KeyByAPIKey(header string) KeyFunc {
+	return func(c *gin.Context) string {
+		k := c.GetHeader(header)
+		if k == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(k))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+}
+
+// RateLimitRule applies Limit to paths under Prefix ("" matches all).
+type RateLimitRule struct {
+	Prefix string
+	Limit  ratelimit.Limit
+}
+
+// rateLimitsKey holds the checks of the RateLimit middlewares a request
+// passed, so SetUser can count it against the user as well.
+const rateLimitsKey = "httpx.rate_limits"
+
+// RateLimit counts each request against the most specific matching rule,
+// once for every key that applies, and answers 429 with Retry-After as soon
+// as one of those quotas is spent. Pass KeyByIP among the keys: the others
+// name clients the caller can choose (an API key header) or are only known
+// after authentication (KeyByUser, counted when SetUser is called).
+// RateLimit-* headers follow the IETF draft and describe the tightest
+// bucket. Limiter errors let the request through.
+func RateLimit(l ratelimit.Limiter, keys []KeyFunc, log *zap.Logger, rules ...RateLimitRule) gin.HandlerFunc {
+	rules = slices.Clone(rules)
+	slices.SortFunc(rules, func(a, b RateLimitRule) int { return len(b.Prefix) - len(a.Prefix) })
+
+	return func(c *gin.Context) {
+		rule, ok := matchRule(rules, c.Request.URL.Path)
+		if !ok {
+			c.Next()
+			return
+		}
+		rl := &rateLimitCheck{l: l, log: log, rule: rule, keys: keys, counted: make([]bool, len(keys)), remaining: -1}
+		if !rl.check(c) {
+			return
+		}
+		checks, _ := c.Value(rateLimitsKey).([]*rateLimitCheck)
+		c.Set(rateLimitsKey, append(checks, rl))
+		c.Next()
+	}
+}
+
+type rateLimitCheck struct {
+	l         ratelimit.Limiter
+	log       *zap.Logger
+	rule      RateLimitRule
+	keys      []KeyFunc
+	counted   []bool
+	remaining int // of the tightest bucket so far, -1 before the first
+}
+
+// check counts the request against each key that is known and was not
+// counted yet. It returns false after failing the request with 429.
+func (r *rateLimitCheck) check(c *gin.Context) bool {
+	for i, key := range r.keys {
+		k := key(c)
+		if r.counted[i] || k == "" {
+			continue
+		}
+		r.counted[i] = true
+		res, err := r.l.Allow(c.Request.Context(), r.rule.Prefix+"|"+k, r.rule.Limit)
+		if err != nil {
+			r.log.Warn("rate limit check failed", zap.String("prefix", r.rule.Prefix), zap.Error(err))
+			continue
+		}
+		if res.Allowed && r.remaining >= 0 && res.Remaining >= r.remaining {
+			continue
+		}
+		r.remaining = res.Remaining
+		h := c.Writer.Header()
+		h.Set("RateLimit-Policy", strconv.Itoa(r.rule.Limit.Requests)+";w="+seconds(r.rule.Limit.Per))
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			h.Set("Retry-After", seconds(max(res.RetryAfter, time.Second)))
+			Fail(c, apperr.RateLimited(""))
+			return false
+		}
+	}
+	return true
+}
+
+// limitUser counts the request against the user in every RateLimit it
+// passed that has a key for it.
+func limitUser(c *gin.Context) {
+	checks, _ := c.Value(rateLimitsKey).([]*rateLimitCheck)
+	for _, rl := range checks {
+		if !rl.check(c) {
+			return
+		}
+	}
+}
+
+func matchRule(rules []RateLimitRule, path string) (RateLimitRule, bool) {
+	for _, r := range rules {
+		p := strings.TrimSuffix(r.Prefix, "/")
+		if p == "" || path == p || strings.HasPrefix(path, p+"/") {
+			return r, true
+		}
+	}
+	return RateLimitRule{}, false
+}
+
+// seconds rounds up, so clients never retry too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
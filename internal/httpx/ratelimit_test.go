@@ -0,0 +1,94 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newRateLimitedRouter(keys []KeyFunc, requests int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RateLimit(ratelimit.NewMemory(), keys, zap.NewNop(), RateLimitRule{
+		Prefix: "/v1",
+		Limit:  ratelimit.Limit{Requests: requests, Per: time.Minute, Algorithm: ratelimit.TokenBucket},
+	}))
+	// stands in for auth middleware
+	auth := func(c *gin.Context) {
+		if id := c.GetHeader("X-User"); id != "" {
+			SetUser(c, id)
+			if c.IsAborted() {
+				return
+			}
+		}
+		c.Next()
+	}
+	r.GET("/v1/things", auth, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func doLimited(r http.Handler, remote string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/things", nil)
+	req.RemoteAddr = remote + ":1234"
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRotatingAPIKeysStillCountAgainstIP(t *testing.T) {
+	r := newRateLimitedRouter(rateLimitKeys(&config.Config{RateLimitKey: "auto", RateLimitAPIKeyHeader: "X-API-Key"}), 3)
+	for i := range 3 {
+		if w := doLimited(r, "10.0.0.1", "X-API-Key", "forged-"+strconv.Itoa(i)); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+	}
+	w := doLimited(r, "10.0.0.1", "X-API-Key", "forged-3")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("fresh API key: status %d, want 429 with Retry-After", w.Code)
+	}
+	if w := doLimited(r, "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("other IP: status %d", w.Code)
+	}
+}
+
+func TestRateLimitCountsUserAfterSetUser(t *testing.T) {
+	r := newRateLimitedRouter(rateLimitKeys(&config.Config{RateLimitKey: "user"}), 2)
+	// the same user from two addresses shares one user bucket
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if w := doLimited(r, ip, "X-User", "u1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+	}
+	w := doLimited(r, "10.0.0.3", "X-User", "u1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("user over quota: status %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want the user bucket's 0", got)
+	}
+	if w := doLimited(r, "10.0.0.3", "X-User", "u2"); w.Code != http.StatusOK {
+		t.Errorf("other user: status %d", w.Code)
+	}
+}
+
+func TestRateLimitHeadersShowTightestBucket(t *testing.T) {
+	r := newRateLimitedRouter(rateLimitKeys(&config.Config{RateLimitKey: "api_key", RateLimitAPIKeyHeader: "X-API-Key"}), 5)
+	doLimited(r, "10.0.0.1", "X-API-Key", "k")
+	doLimited(r, "10.0.0.1", "X-API-Key", "k")
+	// IP bucket: 2 left; key bucket: 2 left; fresh key: 4 left
+	w := doLimited(r, "10.0.0.1", "X-API-Key", "other")
+	if got := w.Header().Get("RateLimit-Remaining"); got != "2" {
+		t.Errorf("RateLimit-Remaining = %q, want 2", got)
+	}
+}
@@ -0,0 +1,60 @@
+package httpx
+
+import (
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"microseed/internal/traffic"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecordOptions struct {
+	SkipPaths    []string
+	SampleRate   float64
+	BodyMaxBytes int
+	Redactor     *Redactor
+}
+
+// Record hands sanitized request/response pairs to rec for
+// `microseed replay`. Credentials are redacted, so replaying
+// authenticated routes needs `--header`; a request whose query or body had
+// a value redacted is marked incomplete, since replaying it would send
+// "[REDACTED]" instead.
+func Record(rec *traffic.Recorder, opts RecordOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(opts.SkipPaths, c.Request.URL.Path) ||
+			(opts.SampleRate < 1 && rand.Float64() >= opts.SampleRate) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		reqBody, reqTruncated := peekBody(c, opts.BodyMaxBytes)
+		w := &bodyWriter{ResponseWriter: c.Writer, max: opts.BodyMaxBytes}
+		c.Writer = w
+
+		c.Next()
+		RenderErrors(c)
+
+		red := opts.Redactor
+		ex := &traffic.Exchange{
+			Time:            start.UTC(),
+			RequestID:       RequestIDFrom(c.Request.Context()),
+			Method:          c.Request.Method,
+			Route:           c.FullPath(),
+			Path:            c.Request.URL.Path,
+			RequestHeaders:  red.Headers(c.Request.Header),
+			Status:          w.Status(),
+			ResponseHeaders: red.Headers(w.Header()),
+			LatencyMs:       float64(time.Since(start).Microseconds()) / 1000,
+		}
+		var queryMasked, bodyMasked, ok bool
+		ex.Query, queryMasked = red.query(c.Request.URL.Query())
+		ex.RequestBody, ok, bodyMasked = red.body(c.ContentType(), reqBody, reqTruncated)
+		ex.RequestIncomplete = !ok || queryMasked || bodyMasked
+		ex.ResponseBody, ok, _ = red.body(w.Header().Get("Content-Type"), w.body.Bytes(), w.truncated)
+		ex.ResponseIncomplete = !ok
+		rec.Record(ex)
+	}
+}
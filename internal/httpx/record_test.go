@@ -0,0 +1,112 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/traffic"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRecordMarksRedactedRequestsIncomplete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	file := filepath.Join(t.TempDir(), "traffic.jsonl")
+	rec := traffic.NewRecorder(&config.Config{TrafficRecordEnabled: true, TrafficRecordFile: file}, zap.NewNop())
+	rec.Start()
+
+	r := gin.New()
+	r.Use(Record(rec, RecordOptions{
+		SampleRate:   1,
+		BodyMaxBytes: 1024,
+		Redactor:     NewRedactor(nil, []string{"password", "email", "token"}),
+	}))
+	r.POST("/v1/things", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"token": "t", "name": "x"})
+	})
+	for _, tc := range []struct{ query, ct, body string }{
+		{"", "application/json", `{"name":"x"}`},
+		{"", "application/json", `{"name":"x","email":"a@example.com"}`},
+		{"", "application/json", `{"items":[{"password":"p"}]}`},
+		{"", "application/x-www-form-urlencoded", `name=x&password=p`},
+		{"?token=t", "application/json", `{"name":"x"}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/things"+tc.query, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.ct)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if err := rec.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []bool
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var ex traffic.Exchange
+		if err := json.Unmarshal(sc.Bytes(), &ex); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ex.RequestIncomplete)
+		// a redacted response value is a diff wildcard, not a reason to skip
+		if ex.ResponseIncomplete || !strings.Contains(ex.ResponseBody, traffic.Redacted) {
+			t.Errorf("response recorded as %q (incomplete=%v)", ex.ResponseBody, ex.ResponseIncomplete)
+		}
+	}
+	want := []bool{false, true, true, true, true}
+	if len(got) != len(want) {
+		t.Fatalf("recorded %d exchanges, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("exchange %d: RequestIncomplete = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRecordRendersPendingErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	file := filepath.Join(t.TempDir(), "traffic.jsonl")
+	rec := traffic.NewRecorder(&config.Config{TrafficRecordEnabled: true, TrafficRecordFile: file}, zap.NewNop())
+	rec.Start()
+
+	r := gin.New()
+	r.Use(Errors(), Record(rec, RecordOptions{SampleRate: 1, BodyMaxBytes: 1024, Redactor: NewRedactor(nil, nil)}))
+	r.GET("/v1/things/:id", func(c *gin.Context) {
+		_ = c.Error(apperr.NotFound("no such thing"))
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/things/1", nil))
+	if err := rec.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ex traffic.Exchange
+	if err := json.Unmarshal(raw, &ex); err != nil {
+		t.Fatal(err)
+	}
+	if ex.Status != http.StatusNotFound || !strings.Contains(ex.ResponseBody, "no such thing") {
+		t.Errorf("recorded %d %q, want the rendered 404", ex.Status, ex.ResponseBody)
+	}
+	if w.Code != http.StatusNotFound || w.Body.String() == "" {
+		t.Errorf("client got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,89 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"microseed/internal/apperr"
+	applog "microseed/internal/log"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Recovery turns a handler panic into a problem+json 500. The panic is
+// logged with its stack through the request logger, recorded on the
+// request span as an exception and counted in http.server.panics.
+// It must run inside otelgin so the span is still open.
+func Recovery(red *Redactor) (gin.HandlerFunc, error) {
+	panics, err := otel.Meter("microseed/http").Int64Counter("http.server.panics",
+		metric.WithDescription("Recovered handler panics"))
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// deliberate abort: let net/http drop the connection
+				panic(rec)
+			}
+			// inner middlewares have replaced c.Request, so the context
+			// carries request ID and logger by now
+			ctx := c.Request.Context()
+			lg := applog.FromContext(ctx)
+			if brokenPipe(rec) {
+				lg.Warn("client connection lost", zap.String("path", c.Request.URL.Path), zap.Any("error", rec))
+				c.Abort()
+				return
+			}
+
+			stack := string(debug.Stack())
+			err, ok := rec.(error)
+			if !ok {
+				err = fmt.Errorf("%v", rec)
+			}
+			span := trace.SpanFromContext(ctx)
+			span.RecordError(err, trace.WithAttributes(
+				attribute.String("exception.stacktrace", stack),
+				attribute.Bool("exception.escaped", true),
+			))
+			span.SetStatus(codes.Error, "panic: "+err.Error())
+			panics.Add(ctx, 1, metric.WithAttributes(attribute.String("http.route", c.FullPath())))
+
+			lg.WithOptions(zap.AddStacktrace(zap.FatalLevel)).Error("panic recovered",
+				zap.Any("panic", rec),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("query", red.Query(c.Request.URL.Query())),
+				zap.Any("headers", red.Headers(c.Request.Header)),
+				zap.String("ip", c.ClientIP()),
+				zap.String("stacktrace", stack),
+			)
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			writeProblem(c, apperr.New(apperr.KindInternal, "internal error"))
+			c.Abort()
+		}()
+		c.Next()
+	}, nil
+}
+
+// brokenPipe : the client went away mid-response; nothing left to write.
+func brokenPipe(rec any) bool {
+	err, ok := rec.(error)
+	return ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
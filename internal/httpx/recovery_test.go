@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestPanicIsAccessLoggedWithRedactedHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.DebugLevel)
+	red := NewRedactor([]string{"X-Custom"}, nil)
+	recovery, err := Recovery(red)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(recovery, ContextLogger(zap.New(core)), AccessLog(AccessLogOptions{SampleRate: 1, Redactor: red}))
+	r.GET("/boom", func(c *gin.Context) { panic("boom") })
+
+	req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	secrets := []string{"Authorization", "X-Auth-Token", "X-Client-Secret", "X-Hub-Signature-256", "X-Api_Key", "Apikey", "X-Custom"}
+	for _, h := range secrets {
+		req.Header.Set(h, "s3cret")
+	}
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d", w.Code)
+	}
+
+	access := logs.FilterMessage("http_request").All()
+	if len(access) != 1 || access[0].ContextMap()["status"] != int64(500) {
+		t.Fatalf("http_request lines = %+v, want one with status 500", access)
+	}
+	panics := logs.FilterMessage("panic recovered").All()
+	if len(panics) != 1 {
+		t.Fatalf("%d panic lines", len(panics))
+	}
+	headers, _ := panics[0].ContextMap()["headers"].(map[string]string)
+	for _, h := range secrets {
+		if v := headers[http.CanonicalHeaderKey(h)]; v != redacted {
+			t.Errorf("%s logged as %q", h, v)
+		}
+	}
+	if headers["Accept"] != "application/json" {
+		t.Errorf("Accept = %q", headers["Accept"])
+	}
+}
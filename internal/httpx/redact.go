@@ -0,0 +1,145 @@
+package httpx
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"microseed/internal/traffic"
+)
+
+const redacted = traffic.Redacted
+
+// sensitiveHeaders are always redacted, whatever ACCESS_LOG_REDACT_HEADERS
+// lists.
+var sensitiveHeaders = []string{"authorization", "cookie", "token", "secret", "password", "api-key", "api_key", "apikey", "signature"}
+
+// Redactor masks credentials and personal data before requests are logged.
+// Names match case-insensitively as substrings, so "password" also covers
+// "new_password" and "cookie" covers "Set-Cookie".
+type Redactor struct {
+	headers []string
+	fields  []string
+}
+
+// NewRedactor masks sensitiveHeaders plus headers, and the given body,
+// form and query fields.
+func NewRedactor(headers, fields []string) *Redactor {
+	return &Redactor{headers: append(slices.Clone(sensitiveHeaders), lower(headers)...), fields: lower(fields)}
+}
+
+func (r *Redactor) Headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, vs := range h {
+		if match(r.headers, k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(vs, ", ")
+	}
+	return out
+}
+
+func (r *Redactor) Query(q url.Values) string {
+	s, _ := r.query(q)
+	return s
+}
+
+// query also reports whether a value was redacted.
+func (r *Redactor) query(q url.Values) (string, bool) {
+	if len(q) == 0 {
+		return "", false
+	}
+	masked := false
+	out := make(url.Values, len(q))
+	for k, vs := range q {
+		if match(r.fields, k) {
+			vs = []string{redacted}
+			masked = true
+		}
+		out[k] = vs
+	}
+	return out.Encode(), masked
+}
+
+// Body renders a captured body for the log. JSON and form bodies are
+// redacted field by field; a truncated one can't be parsed, so only its
+// size is reported. Other content types are logged as text only when
+// they are text/*.
+func (r *Redactor) Body(contentType string, b []byte, truncated bool) string {
+	s, _, _ := r.body(contentType, b, truncated)
+	return s
+}
+
+// body also reports whether the result is the complete (redacted) body and
+// whether a value was redacted.
+func (r *Redactor) body(contentType string, b []byte, truncated bool) (s string, complete, masked bool) {
+	if len(b) == 0 {
+		return "", !truncated, false
+	}
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		var v any
+		if truncated || json.Unmarshal(b, &v) != nil {
+			return "[unparsed json omitted]", false, false
+		}
+		out, _ := json.Marshal(r.walk(v, &masked))
+		return string(out), true, masked
+	case mt == "application/x-www-form-urlencoded":
+		q, err := url.ParseQuery(string(b))
+		if truncated || err != nil {
+			return "[unparsed form omitted]", false, false
+		}
+		s, masked = r.query(q)
+		return s, true, masked
+	case strings.HasPrefix(mt, "text/"):
+		if truncated {
+			return string(b) + "…", false, false
+		}
+		return string(b), true, false
+	}
+	return "[" + mt + " omitted]", false, false
+}
+
+func (r *Redactor) walk(v any, masked *bool) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, x := range t {
+			if match(r.fields, k) {
+				t[k] = redacted
+				*masked = true
+				continue
+			}
+			t[k] = r.walk(x, masked)
+		}
+	case []any:
+		for i, x := range t {
+			t[i] = r.walk(x, masked)
+		}
+	}
+	return v
+}
+
+func match(names []string, key string) bool {
+	key = strings.ToLower(key)
+	for _, n := range names {
+		if strings.Contains(key, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func lower(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+
+	applog "microseed/internal/log"
+	"microseed/pkg/id"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// RequestID keeps a valid incoming X-Request-ID (set by a gateway or an
+// upstream service) or generates a UUIDv7. The ID is echoed in the response,
+// stored in the request context (RequestIDFrom) and on the active span, so
+// it must run after otelgin.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rid := c.GetHeader(RequestIDHeader)
+		if !validRequestID(rid) {
+			rid = id.New()
+		}
+		c.Writer.Header().Set(RequestIDHeader, rid)
+		c.Set("request_id", rid)
+		ctx := applog.WithRequestID(c.Request.Context(), rid)
+		c.Request = c.Request.WithContext(ctx)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", rid))
+		c.Next()
+	}
+}
+
+// RequestIDFrom returns the request ID carried by ctx, or "".
+func RequestIDFrom(ctx context.Context) string {
+	return applog.RequestID(ctx)
+}
+
+// validRequestID accepts 1-128 characters from [A-Za-z0-9._:+/=-], enough
+// for UUIDs, ULIDs and common gateway formats, and nothing that could
+// break log lines or headers.
+func validRequestID(s string) bool {
+	if s == "" || len(s) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':', ch == '+', ch == '/', ch == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Transport adds X-Request-ID from the request context to outbound calls.
+// A nil base uses http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{base}
+}
+
+type roundTripper struct {
+	base http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rid := RequestIDFrom(req.Context())
+	if rid == "" || req.Header.Get(RequestIDHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestIDHeader, rid)
+	return t.base.RoundTrip(req)
+}
@@ -1,17 +1,64 @@
 package httpx
 
 import (
+	"fmt"
+
 	"microseed/internal/config"
+	"microseed/internal/lifecycle"
+	"microseed/internal/ratelimit"
+	"microseed/internal/traffic"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func NewRouter(cfg *config.Config, logger *zap.Logger) *gin.Engine {
+func NewRouter(cfg *config.Config, logger *zap.Logger, state *lifecycle.State, limiter ratelimit.Limiter, rec *traffic.Recorder) (*gin.Engine, error) {
+	mws, err := Middlewares(cfg, logger, state)
+	if err != nil {
+		return nil, err
+	}
 	r := gin.New()
-	for _, m := range Middlewares(logger) {
-		r.Use(m)
+	// ClientIP keys rate limits and idempotency: only believe
+	// X-Forwarded-For from our own proxies
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("httpx: TRUSTED_PROXIES: %w", err)
+	}
+	r.Use(mws...)
+	if rec != nil {
+		r.Use(Record(rec, RecordOptions{
+			SkipPaths:    cfg.AccessLogSkipPaths,
+			SampleRate:   cfg.TrafficRecordSampleRate,
+			BodyMaxBytes: cfg.TrafficRecordMaxBodyBytes,
+			Redactor:     NewRedactor(cfg.AccessLogRedactHeaders, cfg.TrafficRecordRedactFields),
+		}))
+	}
+	if len(cfg.RateLimitRules) > 0 {
+		rules := make([]RateLimitRule, len(cfg.RateLimitRules))
+		for i, rr := range cfg.RateLimitRules {
+			rules[i] = RateLimitRule{Prefix: rr.Prefix, Limit: ratelimit.Limit{
+				Requests:  rr.Requests,
+				Per:       rr.Per,
+				Algorithm: ratelimit.Algorithm(cfg.RateLimitAlgorithm),
+			}}
+		}
+		r.Use(RateLimit(limiter, rateLimitKeys(cfg), logger, rules...))
+	}
+	return r, nil
+}
+
+// rateLimitKeys : RATE_LIMIT_KEY -> KeyFuncs. The client IP is always
+// counted: API key headers are not validated here, and users are only
+// known once auth calls SetUser.
+func rateLimitKeys(cfg *config.Config) []KeyFunc {
+	apiKey := KeyByAPIKey(cfg.RateLimitAPIKeyHeader)
+	switch cfg.RateLimitKey {
+	case "ip":
+		return []KeyFunc{KeyByIP}
+	case "api_key":
+		return []KeyFunc{KeyByIP, apiKey}
+	case "user":
+		return []KeyFunc{KeyByIP, KeyByUser}
+	default:
+		return []KeyFunc{KeyByIP, apiKey, KeyByUser}
 	}
-	_ = cfg // reserved for future toggles/flags
-	return r
 }
@@ -0,0 +1,61 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/lifecycle"
+	"microseed/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newTestRouter(t *testing.T, trusted []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, err := NewRouter(&config.Config{
+		TrustedProxies:     trusted,
+		RateLimitKey:       "ip",
+		RateLimitAlgorithm: string(ratelimit.TokenBucket),
+		RateLimitRules:     []config.RateLimitRule{{Prefix: "/v1", Requests: 2, Per: time.Minute}},
+	}, zap.NewNop(), lifecycle.New(), ratelimit.NewMemory(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/v1/things", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+	return r
+}
+
+func TestRouterIgnoresSpoofedForwardedFor(t *testing.T) {
+	r := newTestRouter(t, nil)
+	for i := range 2 {
+		w := doLimited(r, "10.0.0.1", "X-Forwarded-For", "203.0.113."+strconv.Itoa(i))
+		if w.Code != http.StatusOK || w.Body.String() != "10.0.0.1" {
+			t.Fatalf("request %d: status %d, client %q", i, w.Code, w.Body.String())
+		}
+	}
+	if w := doLimited(r, "10.0.0.1", "X-Forwarded-For", "203.0.113.9"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("fresh X-Forwarded-For: status %d, want 429", w.Code)
+	}
+}
+
+func TestRouterTrustsConfiguredProxies(t *testing.T) {
+	r := newTestRouter(t, []string{"10.0.0.0/8"})
+	for i := range 3 {
+		w := doLimited(r, "10.0.0.1", "X-Forwarded-For", "203.0.113."+strconv.Itoa(i))
+		if want := "203.0.113." + strconv.Itoa(i); w.Code != http.StatusOK || w.Body.String() != want {
+			t.Fatalf("request %d: status %d, client %q, want %q", i, w.Code, w.Body.String(), want)
+		}
+	}
+}
+
+func TestRouterRejectsInvalidTrustedProxies(t *testing.T) {
+	_, err := NewRouter(&config.Config{TrustedProxies: []string{"not-an-ip"}}, zap.NewNop(), lifecycle.New(), ratelimit.NewMemory(), nil)
+	if err == nil {
+		t.Fatal("expected an error for an invalid TRUSTED_PROXIES entry")
+	}
+}
@@ -0,0 +1,244 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/httpx"
+	"microseed/internal/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newTestRedisStore(t *testing.T) (Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr, rdb := testutil.Redis(t)
+	return NewRedisStore(rdb, "app:idempotency:"), mr
+}
+
+// newTestPostgresStore runs the Postgres store's SQL on SQLite.
+func newTestPostgresStore(t *testing.T) Store {
+	t.Helper()
+	db := testutil.SQLite(t)
+	err := db.Exec(`CREATE TABLE idempotency_keys (
+		key TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		owner TEXT NOT NULL DEFAULT '',
+		done BOOLEAN NOT NULL DEFAULT FALSE,
+		status INT NOT NULL DEFAULT 0,
+		header TEXT NOT NULL DEFAULT '{}',
+		body BLOB NOT NULL DEFAULT '',
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPostgresStore(db)
+}
+
+var stores = map[string]func(*testing.T) Store{
+	"redis": func(t *testing.T) Store {
+		s, _ := newTestRedisStore(t)
+		return s
+	},
+	"postgres": newTestPostgresStore,
+}
+
+func TestStoreOwnerOnly(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			ctx := context.Background()
+
+			if _, created, err := s.Begin(ctx, "k", "fp", "old", time.Minute); err != nil || !created {
+				t.Fatalf("Begin = %v, %v", created, err)
+			}
+			rec, created, err := s.Begin(ctx, "k", "fp", "other", time.Minute)
+			if err != nil || created || rec.Done || rec.Owner != "old" {
+				t.Fatalf("second Begin = %+v, %v, %v", rec, created, err)
+			}
+			// neither a stranger's release nor its response touch the reservation
+			if err := s.Release(ctx, "k", "other"); err != nil {
+				t.Fatal(err)
+			}
+			err = s.Complete(ctx, "k", "other", &Record{Fingerprint: "fp", Done: true, Status: 201}, time.Hour)
+			if !errors.Is(err, ErrNotOwner) {
+				t.Fatalf("stranger's Complete err = %v, want ErrNotOwner", err)
+			}
+			if rec, _, _ := s.Begin(ctx, "k", "fp", "other", time.Minute); rec == nil || rec.Done || rec.Owner != "old" {
+				t.Fatalf("record after stranger's writes = %+v", rec)
+			}
+
+			err = s.Complete(ctx, "k", "old", &Record{Fingerprint: "fp", Done: true, Status: 201, Body: []byte("ok")}, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// released after completion by a late owner: must stay
+			if err := s.Release(ctx, "k", "old"); err != nil {
+				t.Fatal(err)
+			}
+			rec, created, err = s.Begin(ctx, "k", "fp", "retry", time.Minute)
+			if err != nil || created || !rec.Done || rec.Status != 201 || string(rec.Body) != "ok" {
+				t.Errorf("Begin after Complete = %+v, %v, %v", rec, created, err)
+			}
+		})
+	}
+}
+
+func TestStoreReleaseThenRetry(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			ctx := context.Background()
+			if _, _, err := s.Begin(ctx, "k", "fp", "a", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Release(ctx, "k", "a"); err != nil {
+				t.Fatal(err)
+			}
+			if _, created, err := s.Begin(ctx, "k", "fp", "b", time.Minute); err != nil || !created {
+				t.Errorf("Begin after Release = %v, %v", created, err)
+			}
+		})
+	}
+}
+
+func newTestRouter(t *testing.T, store Store, lockTTL time.Duration, h gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	m := NewMiddleware(&config.Config{
+		IdempotencyTTL:        time.Hour,
+		IdempotencyLockTTL:    lockTTL,
+		RateLimitAPIKeyHeader: "X-API-Key",
+	}, store, zap.NewNop())
+	r := gin.New()
+	r.Use(httpx.Errors())
+	auth := func(c *gin.Context) {
+		if u := c.GetHeader("X-User"); u != "" {
+			httpx.SetUser(c, u)
+		}
+	}
+	r.POST("/v1/orders", auth, m.Handle, h)
+	r.POST("/v1/refunds", auth, m.Handle, h)
+	return r
+}
+
+func post(r http.Handler, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(KeyHeader, "k1")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareScopesKeysByClientAndRoute(t *testing.T) {
+	var runs atomic.Int32
+	store, _ := newTestRedisStore(t)
+	r := newTestRouter(t, store, time.Minute, func(c *gin.Context) {
+		c.String(http.StatusCreated, "run %d for %s", runs.Add(1), c.GetHeader("X-User"))
+	})
+
+	first := post(r, "/v1/orders", `{}`, "X-User", "alice")
+	replay := post(r, "/v1/orders", `{}`, "X-User", "alice")
+	if replay.Header().Get("Idempotent-Replayed") != "true" || replay.Body.String() != first.Body.String() {
+		t.Fatalf("retry not replayed: %q", replay.Body.String())
+	}
+	for _, w := range []*httptest.ResponseRecorder{
+		post(r, "/v1/orders", `{}`, "X-User", "mallory"),
+		post(r, "/v1/orders", `{}`, "X-API-Key", "key-1"),
+		post(r, "/v1/refunds", `{}`, "X-User", "alice"),
+	} {
+		if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("other client or route got %d %q", w.Code, w.Body.String())
+		}
+	}
+	if n := runs.Load(); n != 4 {
+		t.Errorf("handler ran %d times, want 4", n)
+	}
+}
+
+// The first request outlives IDEMPOTENCY_LOCK_TTL, a retry takes the key
+// over and completes; whatever the first one ends with must not replace or
+// drop the retry's response.
+func TestMiddlewareLateRequestKeepsRetryResponse(t *testing.T) {
+	for _, lateStatus := range []int{http.StatusCreated, http.StatusInternalServerError} {
+		t.Run(http.StatusText(lateStatus), func(t *testing.T) {
+			store, mr := newTestRedisStore(t)
+			started, finish := make(chan struct{}), make(chan struct{})
+			var runs atomic.Int32
+			r := newTestRouter(t, store, time.Second, func(c *gin.Context) {
+				if runs.Add(1) == 1 {
+					close(started)
+					<-finish
+					c.String(lateStatus, "late")
+					return
+				}
+				c.String(http.StatusCreated, "retry")
+			})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				post(r, "/v1/orders", `{}`)
+			}()
+			<-started
+			mr.FastForward(2 * time.Second) // the reservation expires
+			if w := post(r, "/v1/orders", `{}`); w.Body.String() != "retry" {
+				t.Fatalf("retry got %d %q", w.Code, w.Body.String())
+			}
+			close(finish)
+			<-done
+
+			w := post(r, "/v1/orders", `{}`)
+			if w.Header().Get("Idempotent-Replayed") != "true" || w.Body.String() != "retry" {
+				t.Errorf("replay = %d %q, want the retry's response", w.Code, w.Body.String())
+			}
+			if n := runs.Load(); n != 2 {
+				t.Errorf("handler ran %d times, want 2", n)
+			}
+		})
+	}
+}
+
+// Handlers that only call c.Error leave rendering to httpx.Errors; the
+// stored response must be the rendered problem, not an empty 200.
+func TestMiddlewareStoresPendingErrors(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	var runs atomic.Int32
+	r := newTestRouter(t, store, time.Minute, func(c *gin.Context) {
+		if runs.Add(1) == 1 {
+			_ = c.Error(errors.New("db down"))
+			return
+		}
+		_ = c.Error(apperr.NotFound("no such order"))
+	})
+
+	if w := post(r, "/v1/orders", `{}`); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first = %d %q, want 500", w.Code, w.Body.String())
+	}
+	w := post(r, "/v1/orders", `{}`)
+	if w.Code != http.StatusNotFound || w.Header().Get("Idempotent-Replayed") != "" || !strings.Contains(w.Body.String(), "no such order") {
+		t.Fatalf("retry after 500 = %d %q, want a fresh 404", w.Code, w.Body.String())
+	}
+	replay := post(r, "/v1/orders", `{}`)
+	if replay.Code != http.StatusNotFound || replay.Header().Get("Idempotent-Replayed") != "true" ||
+		replay.Body.String() != w.Body.String() || replay.Header().Get("Content-Type") != apperr.ProblemContentType {
+		t.Errorf("replay = %d %q %q, want the stored 404 problem", replay.Code, replay.Header().Get("Content-Type"), replay.Body.String())
+	}
+	if n := runs.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want 2", n)
+	}
+}
@@ -0,0 +1,186 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/httpx"
+	"microseed/pkg/id"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	KeyHeader = "Idempotency-Key"
+	maxKeyLen = 255
+)
+
+// Headers that describe this particular response, not the stored one.
+var notReplayed = map[string]bool{
+	"Content-Length":      true,
+	"Date":                true,
+	"Retry-After":         true,
+	"Set-Cookie":          true,
+	"X-Request-Id":        true,
+	"Ratelimit-Limit":     true,
+	"Ratelimit-Policy":    true,
+	"Ratelimit-Remaining": true,
+	"Ratelimit-Reset":     true,
+}
+
+// Middleware makes POST/PUT/PATCH safe to retry when the client sends an
+// Idempotency-Key. Routes opt in by adding Handle before their handler:
+//
+//	v1.POST("/users", h.Idem.Handle, h.create)
+//
+// The first request runs and its response is stored; retries with the same
+// key and body get the stored response, a retry that arrives while the first
+// is still running gets 409, and the same key with a different request gets
+// 422. 5xx responses and panics are not stored, so those can be retried.
+//
+// Keys are scoped by client (user, else API key, else IP) and route.
+type Middleware struct {
+	store   Store
+	ttl     time.Duration
+	lockTTL time.Duration
+	apiKey  httpx.KeyFunc
+	log     *zap.Logger
+}
+
+func NewMiddleware(cfg *config.Config, store Store, log *zap.Logger) *Middleware {
+	return &Middleware{
+		store:   store,
+		ttl:     cfg.IdempotencyTTL,
+		lockTTL: cfg.IdempotencyLockTTL,
+		apiKey:  httpx.KeyByAPIKey(cfg.RateLimitAPIKeyHeader),
+		log:     log,
+	}
+}
+
+func (i *Middleware) Handle(c *gin.Context) {
+	key := c.GetHeader(KeyHeader)
+	switch c.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		key = ""
+	}
+	if key == "" {
+		c.Next()
+		return
+	}
+	if len(key) > maxKeyLen {
+		httpx.Fail(c, apperr.Validation("invalid Idempotency-Key"))
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		httpx.Fail(c, apperr.Validation("invalid body"))
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	sum := sha256.Sum256([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n" + string(body)))
+	fingerprint := hex.EncodeToString(sum[:])
+
+	ctx := c.Request.Context()
+	key = i.scoped(c, key)
+	owner := id.New()
+	rec, created, err := i.store.Begin(ctx, key, fingerprint, owner, i.lockTTL)
+	if err != nil {
+		i.log.Warn("idempotency store unavailable", zap.Error(err))
+		c.Next()
+		return
+	}
+	if !created {
+		switch {
+		case rec.Fingerprint != fingerprint:
+			httpx.Fail(c, apperr.New(apperr.KindUnprocessable, "Idempotency-Key reused with a different request"))
+		case !rec.Done:
+			c.Header("Retry-After", "1")
+			httpx.Fail(c, apperr.Conflict("request with this Idempotency-Key is still in progress"))
+		default:
+			for k, vs := range rec.Header {
+				for _, v := range vs {
+					c.Writer.Header().Add(k, v)
+				}
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(rec.Status, rec.Header.Get("Content-Type"), rec.Body)
+			c.Abort()
+		}
+		return
+	}
+
+	w := &captureWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	stored := false
+	defer func() {
+		if !stored {
+			// 5xx or panic: let the client retry with the same key
+			if err := i.store.Release(context.WithoutCancel(ctx), key, owner); err != nil {
+				i.log.Warn("idempotency release failed", zap.Error(err))
+			}
+		}
+	}()
+
+	c.Next()
+	httpx.RenderErrors(c)
+
+	if w.Status() >= http.StatusInternalServerError {
+		return
+	}
+	header := http.Header{}
+	for k, vs := range w.Header() {
+		if !notReplayed[http.CanonicalHeaderKey(k)] && !strings.HasPrefix(k, "Idempotent-") {
+			header[k] = vs
+		}
+	}
+	rec = &Record{Fingerprint: fingerprint, Done: true, Status: w.Status(), Header: header, Body: w.body.Bytes()}
+	if err := i.store.Complete(context.WithoutCancel(ctx), key, owner, rec, i.ttl); err != nil {
+		i.log.Warn("idempotency store failed", zap.Error(err))
+		return
+	}
+	stored = true
+}
+
+// scoped returns the store key for the client's key on this route.
+func (i *Middleware) scoped(c *gin.Context, key string) string {
+	client := httpx.KeyByUser(c)
+	if client == "" {
+		client = i.apiKey(c)
+	}
+	if client == "" {
+		client = httpx.KeyByIP(c)
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	sum := sha256.Sum256([]byte(client + "\n" + c.Request.Method + " " + route + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// captureWriter keeps a copy of the body while writing it through.
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
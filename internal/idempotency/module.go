@@ -0,0 +1,14 @@
+package idempotency
+
+import "go.uber.org/fx"
+
+var Module = fx.Options(
+	fx.Provide(
+		New,
+		NewMiddleware,
+		fx.Annotate(
+			CleanupTask,
+			fx.ResultTags(`group:"cron"`),
+		),
+	),
+)
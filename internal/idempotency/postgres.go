@@ -0,0 +1,91 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"microseed/internal/scheduler"
+
+	"gorm.io/gorm"
+)
+
+// PostgresStore keeps records in idempotency_keys. expires_at covers both
+// the reservation (lockTTL) and the stored response (ttl); an expired row
+// is taken over by the next Begin, and CleanupTask deletes the rest.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+type row struct {
+	Fingerprint string
+	Owner       string
+	Done        bool
+	Status      int
+	Header      []byte
+	Body        []byte
+}
+
+func (s *PostgresStore) Begin(ctx context.Context, key, fingerprint, owner string, lockTTL time.Duration) (*Record, bool, error) {
+	res := s.db.WithContext(ctx).Exec(`
+		INSERT INTO idempotency_keys (key, fingerprint, owner, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint, owner = EXCLUDED.owner, done = FALSE, status = 0, header = '{}', body = '',
+			expires_at = EXCLUDED.expires_at, created_at = NOW()
+		WHERE idempotency_keys.expires_at < NOW()`,
+		key, fingerprint, owner, time.Now().Add(lockTTL))
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	var r row
+	err := s.db.WithContext(ctx).Raw(
+		`SELECT fingerprint, owner, done, status, header, body FROM idempotency_keys WHERE key = ?`, key,
+	).Scan(&r).Error
+	if err != nil {
+		return nil, false, err
+	}
+	rec := &Record{Fingerprint: r.Fingerprint, Owner: r.Owner, Done: r.Done, Status: r.Status, Body: r.Body}
+	_ = json.Unmarshal(r.Header, &rec.Header)
+	return rec, false, nil
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, key, owner string, rec *Record, ttl time.Duration) error {
+	header, err := json.Marshal(rec.Header)
+	if err != nil {
+		return err
+	}
+	res := s.db.WithContext(ctx).Exec(`
+		UPDATE idempotency_keys SET done = TRUE, status = ?, header = ?, body = ?, expires_at = ?
+		WHERE key = ? AND owner = ? AND NOT done`,
+		rec.Status, header, rec.Body, time.Now().Add(ttl), key, owner)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key, owner string) error {
+	return s.db.WithContext(ctx).Exec(`DELETE FROM idempotency_keys WHERE key = ? AND owner = ? AND NOT done`, key, owner).Error
+}
+
+// CleanupTask deletes expired rows; it does nothing for the Redis store.
+func CleanupTask(store Store) scheduler.Task {
+	return scheduler.NewTask("idempotency.cleanup", "@hourly", func(ctx context.Context) error {
+		pg, ok := store.(*PostgresStore)
+		if !ok {
+			return nil
+		}
+		return pg.db.WithContext(ctx).Exec(`DELETE FROM idempotency_keys WHERE expires_at < NOW()`).Error
+	})
+}
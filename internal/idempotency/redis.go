@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Replace the record in KEYS[1] with ARGV[2] (ttl ARGV[3] ms), or delete it
+// when ARGV[2] is empty, if it is still an unfinished reservation owned by
+// ARGV[1]. Returns 1 on success.
+var ownedUpdateScript = redis.NewScript(`
+local cur = redis.call('GET', KEYS[1])
+if not cur then return 0 end
+local rec = cjson.decode(cur)
+if rec.owner ~= ARGV[1] or rec.done then return 0 end
+if ARGV[2] == '' then
+  redis.call('DEL', KEYS[1])
+else
+  redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+end
+return 1
+`)
+
+// RedisStore keeps one JSON record per key under <prefix><key>; Redis
+// expiry drops stale reservations and old responses.
+type RedisStore struct {
+	rdb    redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(rdb redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{rdb: rdb, prefix: prefix}
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint, owner string, lockTTL time.Duration) (*Record, bool, error) {
+	raw, _ := json.Marshal(Record{Fingerprint: fingerprint, Owner: owner})
+	ok, err := s.rdb.SetNX(ctx, s.prefix+key, raw, lockTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		return nil, true, nil
+	}
+	b, err := s.rdb.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// expired in between: try once more
+		return s.Begin(ctx, key, fingerprint, owner, lockTTL)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var rec Record
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, false, err
+	}
+	return &rec, false, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key, owner string, rec *Record, ttl time.Duration) error {
+	rec.Owner = owner
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	n, err := ownedUpdateScript.Run(ctx, s.rdb, []string{s.prefix + key}, owner, raw, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key, owner string) error {
+	return ownedUpdateScript.Run(ctx, s.rdb, []string{s.prefix + key}, owner, "", 0).Err()
+}
@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"microseed/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// ErrNotOwner is returned by Complete when the reservation expired and was
+// taken over by another request.
+var ErrNotOwner = errors.New("idempotency: reservation taken over")
+
+// Record is what is kept per Idempotency-Key: the request fingerprint, the
+// owner token of the request holding the reservation and, once that request
+// finished, its response.
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Owner       string      `json:"owner,omitempty"`
+	Done        bool        `json:"done"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+type Store interface {
+	// Begin reserves key for lockTTL on behalf of owner. When the key is
+	// already taken it returns the existing record and created=false.
+	Begin(ctx context.Context, key, fingerprint, owner string, lockTTL time.Duration) (rec *Record, created bool, err error)
+	// Complete stores the response, kept for ttl, if owner still holds the
+	// reservation; otherwise it returns ErrNotOwner.
+	Complete(ctx context.Context, key, owner string, rec *Record, ttl time.Duration) error
+	// Release drops owner's reservation so the request can be retried. It
+	// does nothing once the key was completed or taken over.
+	Release(ctx context.Context, key, owner string) error
+}
+
+func New(cfg *config.Config, rdb redis.UniversalClient, gdb *gorm.DB) (Store, error) {
+	switch cfg.IdempotencyStore {
+	case "", "redis":
+		return NewRedisStore(rdb, cfg.AppName+":idempotency:"), nil
+	case "postgres":
+		return NewPostgresStore(gdb), nil
+	default:
+		return nil, fmt.Errorf("idempotency: unknown store %q", cfg.IdempotencyStore)
+	}
+}
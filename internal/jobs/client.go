@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+
+	"microseed/internal/config"
+	applog "microseed/internal/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type Client struct {
+	queue    Queue
+	maxRetry int
+}
+
+func NewClient(q Queue, cfg *config.Config) *Client {
+	return &Client{queue: q, maxRetry: cfg.JobsMaxRetry}
+}
+
+// Enqueue marshals payload to JSON and pushes a job of the given type.
+func (c *Client) Enqueue(ctx context.Context, typ string, payload any, opts ...Option) (*Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := newJob(typ, raw, c.maxRetry)
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if rid := applog.RequestID(ctx); rid != "" {
+		carrier[requestIDKey] = rid
+	}
+	if len(carrier) > 0 {
+		job.Trace = carrier
+	}
+
+	if err := c.queue.Push(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
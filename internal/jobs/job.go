@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"microseed/pkg/backoff"
+	"microseed/pkg/id"
+)
+
+const DefaultQueue = "default"
+
+// DefaultUniqueFor caps uniqueness locks enqueued without a ttl.
+const DefaultUniqueFor = 24 * time.Hour
+
+// requestIDKey carries the enqueuing request's ID in Job.Trace.
+const requestIDKey = "x-request-id"
+
+var ErrDuplicate = errors.New("jobs: duplicate unique job")
+
+type Job struct {
+	ID        string            `json:"id"`
+	Queue     string            `json:"queue"`
+	Type      string            `json:"type"`
+	Payload   json.RawMessage   `json:"payload,omitempty"`
+	Attempt   int               `json:"attempt"`
+	MaxRetry  int               `json:"max_retry"`
+	UniqueKey string            `json:"unique_key,omitempty"`
+	UniqueFor time.Duration     `json:"unique_for,omitempty"`
+	RunAt     time.Time         `json:"run_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	LastError string            `json:"last_error,omitempty"`
+	Trace     map[string]string `json:"trace,omitempty"` // propagated span context (+ request ID)
+
+	raw string // payload as popped, identifies the job in a processing list
+}
+
+// Bind decodes the job payload into v.
+func (j *Job) Bind(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+type Handler interface {
+	Type() string
+	Handle(ctx context.Context, job *Job) error
+}
+
+type handlerFunc struct {
+	typ string
+	fn  func(ctx context.Context, job *Job) error
+}
+
+func (h handlerFunc) Type() string { return h.typ }
+
+func (h handlerFunc) Handle(ctx context.Context, job *Job) error { return h.fn(ctx, job) }
+
+// HandlerFunc wraps fn as a Handler for the given job type.
+func HandlerFunc(typ string, fn func(ctx context.Context, job *Job) error) Handler {
+	return handlerFunc{typ: typ, fn: fn}
+}
+
+type Option func(*Job)
+
+func OnQueue(name string) Option {
+	return func(j *Job) { j.Queue = name }
+}
+
+func Delay(d time.Duration) Option {
+	return func(j *Job) { j.RunAt = time.Now().Add(d) }
+}
+
+func At(t time.Time) Option {
+	return func(j *Job) { j.RunAt = t }
+}
+
+func MaxRetry(n int) Option {
+	return func(j *Job) { j.MaxRetry = n }
+}
+
+// Unique rejects enqueues with the same key (ErrDuplicate) until the job
+// finishes or ttl expires, whichever comes first. ttl <= 0 means
+// DefaultUniqueFor, so a lost job can't hold the key forever.
+func Unique(key string, ttl time.Duration) Option {
+	return func(j *Job) {
+		j.UniqueKey = key
+		j.UniqueFor = ttl
+	}
+}
+
+func uniqueTTL(job *Job) time.Duration {
+	if job.UniqueFor <= 0 {
+		return DefaultUniqueFor
+	}
+	return job.UniqueFor
+}
+
+func newJob(typ string, payload json.RawMessage, maxRetry int) *Job {
+	now := time.Now()
+	return &Job{
+		ID:        id.New(),
+		Queue:     DefaultQueue,
+		Type:      typ,
+		Payload:   payload,
+		MaxRetry:  maxRetry,
+		RunAt:     now,
+		CreatedAt: now,
+	}
+}
+
+// Backoff : exponential (1s, 2s, 4s, ...) capped at 1h, with up to 20% jitter.
+func Backoff(attempt int) time.Duration {
+	return backoff.Exponential(attempt, time.Second, time.Hour)
+}
@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryQueue is an in-process Queue for tests and local development.
+// Jobs are lost on restart.
+type MemoryQueue struct {
+	mu        sync.Mutex
+	ready     map[string][]*Job
+	scheduled map[string][]*Job
+	dead      map[string][]*Job
+	unique    map[string]uniqueLock
+	notify    chan struct{}
+}
+
+type uniqueLock struct {
+	jobID   string
+	expires time.Time
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		ready:     map[string][]*Job{},
+		scheduled: map[string][]*Job{},
+		dead:      map[string][]*Job{},
+		unique:    map[string]uniqueLock{},
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+func (q *MemoryQueue) Push(_ context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job.UniqueKey != "" {
+		if l, ok := q.unique[job.UniqueKey]; ok && time.Now().Before(l.expires) {
+			return ErrDuplicate
+		}
+		q.unique[job.UniqueKey] = uniqueLock{jobID: job.ID, expires: time.Now().Add(uniqueTTL(job))}
+	}
+	q.storeLocked(job)
+	return nil
+}
+
+func (q *MemoryQueue) storeLocked(job *Job) {
+	cp := *job
+	if cp.RunAt.After(time.Now()) {
+		q.scheduled[cp.Queue] = append(q.scheduled[cp.Queue], &cp)
+		return
+	}
+	q.ready[cp.Queue] = append(q.ready[cp.Queue], &cp)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *MemoryQueue) Pop(ctx context.Context, queue string, timeout time.Duration) (*Job, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(10 * time.Millisecond) // picks up scheduled jobs
+	defer tick.Stop()
+
+	for {
+		if job := q.take(queue); job != nil {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, nil
+		case <-q.notify:
+		case <-tick.C:
+		}
+	}
+}
+
+func (q *MemoryQueue) take(queue string) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	pending := q.scheduled[queue][:0]
+	for _, j := range q.scheduled[queue] {
+		if j.RunAt.After(now) {
+			pending = append(pending, j)
+			continue
+		}
+		q.ready[queue] = append(q.ready[queue], j)
+	}
+	q.scheduled[queue] = pending
+
+	if len(q.ready[queue]) == 0 {
+		return nil
+	}
+	job := q.ready[queue][0]
+	q.ready[queue] = q.ready[queue][1:]
+	return job
+}
+
+func (q *MemoryQueue) Ack(_ context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked(job)
+	return nil
+}
+
+func (q *MemoryQueue) Retry(_ context.Context, job *Job, at time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.RunAt = at
+	q.storeLocked(job)
+	return nil
+}
+
+func (q *MemoryQueue) Kill(_ context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cp := *job
+	q.dead[job.Queue] = append(q.dead[job.Queue], &cp)
+	q.releaseLocked(job)
+	return nil
+}
+
+func (q *MemoryQueue) releaseLocked(job *Job) {
+	if l, ok := q.unique[job.UniqueKey]; ok && l.jobID == job.ID {
+		delete(q.unique, job.UniqueKey)
+	}
+}
+
+// Pending returns ready and scheduled jobs of a queue ordered by RunAt.
+func (q *MemoryQueue) Pending(queue string) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]Job, 0, len(q.ready[queue])+len(q.scheduled[queue]))
+	for _, j := range q.ready[queue] {
+		out = append(out, *j)
+	}
+	for _, j := range q.scheduled[queue] {
+		out = append(out, *j)
+	}
+	sort.SliceStable(out, func(i, k int) bool { return out[i].RunAt.Before(out[k].RunAt) })
+	return out
+}
+
+// Dead returns the dead-letter jobs of a queue.
+func (q *MemoryQueue) Dead(queue string) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]Job, 0, len(q.dead[queue]))
+	for _, j := range q.dead[queue] {
+		out = append(out, *j)
+	}
+	return out
+}
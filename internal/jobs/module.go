@@ -0,0 +1,15 @@
+package jobs
+
+import "go.uber.org/fx"
+
+// Module provides the queue and the enqueue Client. Handlers are contributed
+// by domain modules with fx.ResultTags(`group:"jobs"`).
+var Module = fx.Options(
+	fx.Provide(NewQueue, NewClient),
+)
+
+// WorkerModule runs the registered handlers (microseed worker).
+var WorkerModule = fx.Options(
+	fx.Provide(NewWorker),
+	fx.Invoke(RegisterHooks),
+)
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"microseed/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Queue interface {
+	// Push stores a job; jobs with RunAt in the future are held until due.
+	Push(ctx context.Context, job *Job) error
+	// Pop waits up to timeout for a ready job; (nil, nil) on timeout.
+	Pop(ctx context.Context, queue string, timeout time.Duration) (*Job, error)
+	Ack(ctx context.Context, job *Job) error
+	// Retry reschedules a failed job at the given time.
+	Retry(ctx context.Context, job *Job, at time.Time) error
+	// Kill moves a job to the dead-letter storage of its queue.
+	Kill(ctx context.Context, job *Job) error
+}
+
+func NewQueue(cfg *config.Config, rdb redis.UniversalClient) (Queue, error) {
+	switch cfg.JobsBackend {
+	case "", "redis":
+		return NewRedisQueue(rdb, cfg.AppName+":jobs:"), nil
+	case "memory":
+		return NewMemoryQueue(), nil
+	default:
+		return nil, fmt.Errorf("jobs: unknown backend %q", cfg.JobsBackend)
+	}
+}
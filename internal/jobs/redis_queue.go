@@ -0,0 +1,285 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"microseed/pkg/id"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	maxDeadJobs = 10000
+
+	// a worker whose heartbeat is older than heartbeatTTL is considered
+	// dead and its in-flight jobs go back to the ready list
+	heartbeatTTL      = 30 * time.Second
+	heartbeatInterval = 5 * time.Second
+	reapInterval      = 30 * time.Second
+)
+
+// promoteScript moves due jobs from the scheduled zset to the ready list.
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, raw in ipairs(due) do
+  redis.call('ZREM', KEYS[1], raw)
+  redis.call('LPUSH', KEYS[2], raw)
+end
+return #due
+`)
+
+// releaseScript drops a uniqueness lock only while it still belongs to the job.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisQueue layout (per queue):
+//
+//	<prefix>queue:{<q>}              list, ready jobs (LPUSH / BLMOVE)
+//	<prefix>processing:{<q>}:<w>     list, jobs in flight on worker w
+//	<prefix>workers:{<q>}            set, workers that popped from q
+//	<prefix>scheduled:{<q>}          zset, delayed + retrying jobs scored by run_at (ms)
+//	<prefix>dead:{<q>}               list, dead-letter jobs (capped)
+//	<prefix>heartbeat:<w>            string with TTL, worker w is alive
+//	<prefix>unique:<key>             string, uniqueness lock
+//
+// Pop moves a job atomically into the worker's processing list; Ack, Retry
+// and Kill remove it. If a worker dies mid-job its heartbeat expires and
+// the reaper (any live worker) pushes the job back to the ready list.
+// The {<q>} hash tag keeps a queue's keys in one Cluster slot, which the
+// promote script and BLMOVE need.
+type RedisQueue struct {
+	rdb    redis.UniversalClient
+	prefix string
+	worker string
+
+	mu         sync.Mutex
+	registered map[string]bool
+	stop       context.CancelFunc
+	done       chan struct{}
+}
+
+func NewRedisQueue(rdb redis.UniversalClient, prefix string) *RedisQueue {
+	return &RedisQueue{rdb: rdb, prefix: prefix, worker: id.New(), registered: map[string]bool{}}
+}
+
+func (q *RedisQueue) readyKey(name string) string     { return q.prefix + "queue:{" + name + "}" }
+func (q *RedisQueue) scheduledKey(name string) string { return q.prefix + "scheduled:{" + name + "}" }
+func (q *RedisQueue) deadKey(name string) string      { return q.prefix + "dead:{" + name + "}" }
+func (q *RedisQueue) uniqueKey(key string) string     { return q.prefix + "unique:" + key }
+func (q *RedisQueue) workersKey(name string) string   { return q.prefix + "workers:{" + name + "}" }
+func (q *RedisQueue) heartbeatKey(w string) string    { return q.prefix + "heartbeat:" + w }
+func (q *RedisQueue) processingKey(name, w string) string {
+	return q.prefix + "processing:{" + name + "}:" + w
+}
+
+func (q *RedisQueue) Push(ctx context.Context, job *Job) error {
+	if job.UniqueKey != "" {
+		ok, err := q.rdb.SetNX(ctx, q.uniqueKey(job.UniqueKey), job.ID, uniqueTTL(job)).Result()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrDuplicate
+		}
+	}
+	if err := q.store(ctx, job); err != nil {
+		if job.UniqueKey != "" {
+			q.rdb.Del(ctx, q.uniqueKey(job.UniqueKey))
+		}
+		return err
+	}
+	return nil
+}
+
+func (q *RedisQueue) store(ctx context.Context, job *Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if job.RunAt.After(time.Now()) {
+		return q.rdb.ZAdd(ctx, q.scheduledKey(job.Queue), redis.Z{
+			Score:  float64(job.RunAt.UnixMilli()),
+			Member: raw,
+		}).Err()
+	}
+	return q.rdb.LPush(ctx, q.readyKey(job.Queue), raw).Err()
+}
+
+func (q *RedisQueue) Pop(ctx context.Context, queue string, timeout time.Duration) (*Job, error) {
+	err := promoteScript.Run(ctx, q.rdb,
+		[]string{q.scheduledKey(queue), q.readyKey(queue)},
+		strconv.FormatInt(time.Now().UnixMilli(), 10), 100,
+	).Err()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	if err := q.register(ctx, queue); err != nil {
+		return nil, err
+	}
+	raw, err := q.rdb.BLMove(ctx, q.readyKey(queue), q.processingKey(queue, q.worker), "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		// unreadable: keep it out of the ready list for good
+		q.rdb.LRem(ctx, q.processingKey(queue, q.worker), 1, raw)
+		q.rdb.LPush(ctx, q.deadKey(queue), raw)
+		return nil, err
+	}
+	job.raw = raw
+	return &job, nil
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, job *Job) error {
+	if err := q.rdb.LRem(ctx, q.processingKey(job.Queue, q.worker), 1, job.raw).Err(); err != nil {
+		return err
+	}
+	return q.releaseUnique(ctx, job)
+}
+
+func (q *RedisQueue) Retry(ctx context.Context, job *Job, at time.Time) error {
+	job.RunAt = at
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	// same slot ({queue}), so the move is one transaction
+	pipe := q.rdb.TxPipeline()
+	pipe.ZAdd(ctx, q.scheduledKey(job.Queue), redis.Z{Score: float64(at.UnixMilli()), Member: raw})
+	pipe.LRem(ctx, q.processingKey(job.Queue, q.worker), 1, job.raw)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (q *RedisQueue) Kill(ctx context.Context, job *Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	pipe := q.rdb.TxPipeline()
+	pipe.LPush(ctx, q.deadKey(job.Queue), raw)
+	pipe.LTrim(ctx, q.deadKey(job.Queue), 0, maxDeadJobs-1)
+	pipe.LRem(ctx, q.processingKey(job.Queue, q.worker), 1, job.raw)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	return q.releaseUnique(ctx, job)
+}
+
+// register marks this worker alive and lists it under queue, so reapers
+// on other workers know where to look for its in-flight jobs.
+func (q *RedisQueue) register(ctx context.Context, queue string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.registered[queue] {
+		return nil
+	}
+	// heartbeat before SADD: a reaper must never see us without one
+	if err := q.rdb.Set(ctx, q.heartbeatKey(q.worker), 1, heartbeatTTL).Err(); err != nil {
+		return err
+	}
+	if err := q.rdb.SAdd(ctx, q.workersKey(queue), q.worker).Err(); err != nil {
+		return err
+	}
+	q.registered[queue] = true
+	return nil
+}
+
+// Start keeps this worker's heartbeat alive and periodically requeues jobs
+// of dead workers. Call before the first Pop; Stop ends it.
+func (q *RedisQueue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.stop, q.done = cancel, make(chan struct{})
+	_ = q.rdb.Set(ctx, q.heartbeatKey(q.worker), 1, heartbeatTTL).Err()
+	go func() {
+		defer close(q.done)
+		beat := time.NewTicker(heartbeatInterval)
+		defer beat.Stop()
+		reap := time.NewTicker(reapInterval)
+		defer reap.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-beat.C:
+				_ = q.rdb.Set(ctx, q.heartbeatKey(q.worker), 1, heartbeatTTL).Err()
+			case <-reap.C:
+				_, _ = q.Reap(ctx)
+			}
+		}
+	}()
+}
+
+// Stop ends the heartbeat. In-flight jobs have been acked or retried by
+// then (Worker.Stop drains first); anything left is reaped right away.
+func (q *RedisQueue) Stop() {
+	if q.stop == nil {
+		return
+	}
+	q.stop()
+	<-q.done
+	ctx := context.Background()
+	q.rdb.Del(ctx, q.heartbeatKey(q.worker))
+	_, _ = q.Reap(ctx)
+}
+
+// Reap moves the in-flight jobs of workers without a heartbeat back to the
+// ready list and returns how many were requeued.
+func (q *RedisQueue) Reap(ctx context.Context) (int, error) {
+	q.mu.Lock()
+	queues := make([]string, 0, len(q.registered))
+	for name := range q.registered {
+		queues = append(queues, name)
+	}
+	q.mu.Unlock()
+
+	n := 0
+	for _, queue := range queues {
+		workers, err := q.rdb.SMembers(ctx, q.workersKey(queue)).Result()
+		if err != nil {
+			return n, err
+		}
+		for _, w := range workers {
+			alive, err := q.rdb.Exists(ctx, q.heartbeatKey(w)).Result()
+			if err != nil {
+				return n, err
+			}
+			if alive > 0 {
+				continue
+			}
+			for {
+				err := q.rdb.LMove(ctx, q.processingKey(queue, w), q.readyKey(queue), "RIGHT", "LEFT").Err()
+				if errors.Is(err, redis.Nil) {
+					break
+				}
+				if err != nil {
+					return n, err
+				}
+				n++
+			}
+			q.rdb.SRem(ctx, q.workersKey(queue), w)
+		}
+	}
+	return n, nil
+}
+
+func (q *RedisQueue) releaseUnique(ctx context.Context, job *Job) error {
+	if job.UniqueKey == "" {
+		return nil
+	}
+	return releaseScript.Run(ctx, q.rdb, []string{q.uniqueKey(job.UniqueKey)}, job.ID).Err()
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"microseed/internal/testutil"
+)
+
+func TestRedisQueueAckRemovesInFlight(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := testutil.Redis(t)
+	q := NewRedisQueue(rdb, "t:")
+
+	if err := q.Push(ctx, newJob("x", nil, 1)); err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.Pop(ctx, DefaultQueue, 100*time.Millisecond)
+	if err != nil || job == nil {
+		t.Fatalf("pop: %v %v", job, err)
+	}
+	inFlight := rdb.LLen(ctx, q.processingKey(DefaultQueue, q.worker)).Val()
+	if inFlight != 1 {
+		t.Fatalf("processing list = %d, want 1", inFlight)
+	}
+	if err := q.Ack(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if n := rdb.LLen(ctx, q.processingKey(DefaultQueue, q.worker)).Val(); n != 0 {
+		t.Fatalf("processing list after ack = %d", n)
+	}
+}
+
+func TestRedisQueueRetryMovesToScheduled(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := testutil.Redis(t)
+	q := NewRedisQueue(rdb, "t:")
+
+	_ = q.Push(ctx, newJob("x", nil, 1))
+	job, _ := q.Pop(ctx, DefaultQueue, 100*time.Millisecond)
+	job.Attempt++
+	if err := q.Retry(ctx, job, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n := rdb.LLen(ctx, q.processingKey(DefaultQueue, q.worker)).Val(); n != 0 {
+		t.Fatalf("processing list after retry = %d", n)
+	}
+	if n := rdb.ZCard(ctx, q.scheduledKey(DefaultQueue)).Val(); n != 1 {
+		t.Fatalf("scheduled = %d, want 1", n)
+	}
+}
+
+func TestRedisQueueReapsDeadWorker(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := testutil.Redis(t)
+	crashed := NewRedisQueue(rdb, "t:")
+	live := NewRedisQueue(rdb, "t:")
+
+	orig := newJob("x", nil, 1)
+	_ = crashed.Push(ctx, orig)
+	if job, _ := crashed.Pop(ctx, DefaultQueue, 100*time.Millisecond); job == nil {
+		t.Fatal("no job")
+	}
+	// the live worker polls the same queue
+	if job, _ := live.Pop(ctx, DefaultQueue, 10*time.Millisecond); job != nil {
+		t.Fatal("job popped twice")
+	}
+
+	// crashed never acks; its heartbeat runs out
+	if n, _ := live.Reap(ctx); n != 0 {
+		t.Fatalf("reaped %d jobs of a live worker", n)
+	}
+	mr.FastForward(heartbeatTTL + time.Second)
+	// keep the live worker's own heartbeat
+	rdb.Set(ctx, live.heartbeatKey(live.worker), 1, heartbeatTTL)
+
+	n, err := live.Reap(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("reap = %d, %v", n, err)
+	}
+	job, _ := live.Pop(ctx, DefaultQueue, 100*time.Millisecond)
+	if job == nil || job.ID != orig.ID {
+		t.Fatalf("requeued job = %+v", job)
+	}
+	if rdb.SIsMember(ctx, live.workersKey(DefaultQueue), crashed.worker).Val() {
+		t.Fatal("dead worker still registered")
+	}
+}
+
+func TestRedisQueueUniqueAlwaysExpires(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := testutil.Redis(t)
+	q := NewRedisQueue(rdb, "t:")
+
+	job := newJob("x", nil, 1)
+	Unique("k", 0)(job)
+	if err := q.Push(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := rdb.TTL(ctx, q.uniqueKey("k")).Val(); ttl <= 0 || ttl > DefaultUniqueFor {
+		t.Fatalf("unique ttl = %v", ttl)
+	}
+}
@@ -0,0 +1,229 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"microseed/internal/config"
+	applog "microseed/internal/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const pollTimeout = time.Second
+
+type Worker struct {
+	queue    Queue
+	handlers map[string]Handler
+	queues   map[string]int
+	log      *zap.Logger
+	tracer   trace.Tracer
+
+	stopPolling context.CancelFunc
+	cancelJobs  context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+type workerIn struct {
+	fx.In
+
+	Queue    Queue
+	Cfg      *config.Config
+	Log      *zap.Logger
+	Handlers []Handler `group:"jobs"`
+}
+
+func NewWorker(in workerIn) *Worker {
+	handlers := make(map[string]Handler, len(in.Handlers))
+	for _, h := range in.Handlers {
+		handlers[h.Type()] = h
+	}
+	return &Worker{
+		queue:    in.Queue,
+		handlers: handlers,
+		queues:   in.Cfg.JobsQueues,
+		log:      in.Log.Named("jobs"),
+		tracer:   otel.Tracer("microseed/jobs"),
+	}
+}
+
+// queueLifecycle : queues with background upkeep (RedisQueue heartbeat
+// and reaper) that only matters while jobs are being popped.
+type queueLifecycle interface {
+	Start()
+	Stop()
+}
+
+func (w *Worker) Start() {
+	if lq, ok := w.queue.(queueLifecycle); ok {
+		lq.Start()
+	}
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	w.stopPolling, w.cancelJobs = stopPolling, cancelJobs
+
+	for q, n := range w.queues {
+		for i := 0; i < n; i++ {
+			w.wg.Add(1)
+			go func() {
+				defer w.wg.Done()
+				w.loop(pollCtx, jobCtx, q)
+			}()
+		}
+		w.log.Info("queue started", zap.String("queue", q), zap.Int("concurrency", n))
+	}
+}
+
+// Stop stops fetching new jobs and waits for in-flight ones to finish.
+// When ctx expires first, running handlers get their context cancelled.
+func (w *Worker) Stop(ctx context.Context) error {
+	if w.stopPolling == nil {
+		return nil
+	}
+	w.stopPolling()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("jobs: drain interrupted: %w", ctx.Err())
+		w.cancelJobs()
+		<-done
+	}
+	w.cancelJobs()
+	if lq, ok := w.queue.(queueLifecycle); ok {
+		lq.Stop()
+	}
+	return err
+}
+
+func (w *Worker) loop(pollCtx, jobCtx context.Context, queue string) {
+	for pollCtx.Err() == nil {
+		job, err := w.queue.Pop(pollCtx, queue, pollTimeout)
+		if err != nil {
+			if pollCtx.Err() != nil {
+				return
+			}
+			w.log.Error("pop failed", zap.String("queue", queue), zap.Error(err))
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-time.After(pollTimeout):
+			}
+			continue
+		}
+		if job == nil {
+			continue
+		}
+		w.process(jobCtx, job)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	if len(job.Trace) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(job.Trace))
+		if rid := job.Trace[requestIDKey]; rid != "" {
+			ctx = applog.WithRequestID(ctx, rid)
+		}
+	}
+	ctx, span := w.tracer.Start(ctx, "job "+job.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", job.Type),
+			attribute.String("job.queue", job.Queue),
+			attribute.Int("job.attempt", job.Attempt),
+		),
+	)
+	defer span.End()
+
+	lg := w.log.With(append(applog.Fields(ctx),
+		zap.String("job_id", job.ID),
+		zap.String("job_type", job.Type),
+		zap.String("queue", job.Queue),
+		zap.Int("attempt", job.Attempt),
+	)...)
+	ctx = applog.WithLogger(ctx, lg)
+
+	start := time.Now()
+	err := w.run(ctx, job)
+	latency := time.Since(start)
+
+	// bookkeeping must survive a cancelled handler context
+	qctx := context.WithoutCancel(ctx)
+	if err == nil {
+		if aerr := w.queue.Ack(qctx, job); aerr != nil {
+			lg.Error("ack failed", zap.Error(aerr))
+		}
+		lg.Info("job done", zap.Duration("latency", latency))
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	job.Attempt++
+	job.LastError = err.Error()
+	if job.Attempt > job.MaxRetry {
+		if kerr := w.queue.Kill(qctx, job); kerr != nil {
+			lg.Error("dead-letter failed", zap.Error(kerr))
+		}
+		lg.Error("job dead", zap.Duration("latency", latency), zap.Error(err))
+		return
+	}
+
+	at := time.Now().Add(Backoff(job.Attempt))
+	if rerr := w.queue.Retry(qctx, job, at); rerr != nil {
+		lg.Error("retry failed", zap.Error(rerr))
+	}
+	lg.Warn("job failed, retrying",
+		zap.Duration("latency", latency),
+		zap.Time("retry_at", at),
+		zap.Error(err),
+	)
+}
+
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	h, ok := w.handlers[job.Type]
+	if !ok {
+		job.MaxRetry = 0
+		return errors.New("jobs: no handler for type " + job.Type)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h.Handle(ctx, job)
+}
+
+func RegisterHooks(lc fx.Lifecycle, w *Worker, cfg *config.Config, log *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			w.Start()
+			log.Info("job worker started", zap.Int("handlers", len(w.handlers)))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			drainCtx, cancel := context.WithTimeout(ctx, cfg.GracefulTimeout)
+			defer cancel()
+			log.Info("draining job worker", zap.Duration("timeout", cfg.GracefulTimeout))
+			return w.Stop(drainCtx)
+		},
+	})
+}
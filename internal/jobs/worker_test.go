@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+func newTestWorker(q Queue, handlers ...Handler) *Worker {
+	w := &Worker{
+		queue:    q,
+		handlers: map[string]Handler{},
+		queues:   map[string]int{DefaultQueue: 2},
+		log:      zap.NewNop(),
+	}
+	for _, h := range handlers {
+		w.handlers[h.Type()] = h
+	}
+	w.tracer = noop.NewTracerProvider().Tracer("test")
+	return w
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWorkerRunsJob(t *testing.T) {
+	q := NewMemoryQueue()
+	var got atomic.Value
+	w := newTestWorker(q, HandlerFunc("greet", func(ctx context.Context, job *Job) error {
+		var p struct{ Name string }
+		if err := job.Bind(&p); err != nil {
+			return err
+		}
+		got.Store(p.Name)
+		return nil
+	}))
+	c := &Client{queue: q, maxRetry: 3}
+	if _, err := c.Enqueue(context.Background(), "greet", map[string]string{"Name": "ana"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop(context.Background())
+
+	waitFor(t, "handler", func() bool { return got.Load() == "ana" })
+}
+
+func TestWorkerRetriesThenDeadLetters(t *testing.T) {
+	q := NewMemoryQueue()
+	var calls atomic.Int32
+	w := newTestWorker(q, HandlerFunc("fail", func(ctx context.Context, job *Job) error {
+		calls.Add(1)
+		return errors.New("boom")
+	}))
+	c := &Client{queue: q, maxRetry: 1}
+	job, err := c.Enqueue(context.Background(), "fail", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop(context.Background())
+
+	waitFor(t, "first attempt", func() bool { return calls.Load() == 1 })
+	// retry is scheduled with backoff; pull it forward
+	waitFor(t, "retry scheduled", func() bool { return len(q.Pending(DefaultQueue)) == 1 })
+	q.mu.Lock()
+	for _, j := range q.scheduled[DefaultQueue] {
+		j.RunAt = time.Now()
+	}
+	q.mu.Unlock()
+
+	waitFor(t, "dead letter", func() bool { return len(q.Dead(DefaultQueue)) == 1 })
+	dead := q.Dead(DefaultQueue)[0]
+	if dead.ID != job.ID || dead.Attempt != 2 || dead.LastError != "boom" {
+		t.Fatalf("dead job = %+v", dead)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls = %d, want 2", n)
+	}
+}
+
+func TestWorkerRecoversPanic(t *testing.T) {
+	q := NewMemoryQueue()
+	w := newTestWorker(q, HandlerFunc("panic", func(ctx context.Context, job *Job) error {
+		panic("kaboom")
+	}))
+	c := &Client{queue: q, maxRetry: 0}
+	if _, err := c.Enqueue(context.Background(), "panic", nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop(context.Background())
+	waitFor(t, "dead letter", func() bool { return len(q.Dead(DefaultQueue)) == 1 })
+}
+
+func TestUniqueJobs(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	c := &Client{queue: q, maxRetry: 3}
+
+	if _, err := c.Enqueue(ctx, "x", nil, Unique("k", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Enqueue(ctx, "x", nil, Unique("k", 0)); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("second enqueue err = %v, want ErrDuplicate", err)
+	}
+	// ttl 0 still expires
+	if l := q.unique["k"]; l.expires.IsZero() || time.Until(l.expires) > DefaultUniqueFor {
+		t.Fatalf("unique lock expiry = %v", l.expires)
+	}
+
+	job, _ := q.Pop(ctx, DefaultQueue, time.Second)
+	if err := q.Ack(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Enqueue(ctx, "x", nil, Unique("k", time.Minute)); err != nil {
+		t.Fatalf("enqueue after ack: %v", err)
+	}
+}
+
+func TestWorkerStopDoesNotWaitOutPopBackoff(t *testing.T) {
+	w := newTestWorker(failingQueue{})
+	w.Start()
+	time.Sleep(20 * time.Millisecond) // in the error backoff now
+	start := time.Now()
+	if err := w.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > pollTimeout/2 {
+		t.Fatalf("Stop took %v", d)
+	}
+}
+
+type failingQueue struct{ Queue }
+
+func (failingQueue) Pop(ctx context.Context, _ string, _ time.Duration) (*Job, error) {
+	return nil, errors.New("redis down")
+}
@@ -0,0 +1,8 @@
+package lifecycle
+
+import "go.uber.org/fx"
+
+// Module provides the State; include RegisterHooks as the app's last invoke.
+var Module = fx.Options(
+	fx.Provide(New),
+)
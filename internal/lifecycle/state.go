@@ -0,0 +1,67 @@
+package lifecycle
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"microseed/internal/config"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// State tracks the process phase for the startup / readiness probes and
+// counts in-flight HTTP requests.
+type State struct {
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+	inflight     atomic.Int64
+}
+
+func New() *State {
+	return &State{}
+}
+
+// Started reports whether every OnStart hook has completed.
+func (s *State) Started() bool { return s.started.Load() }
+
+// ShuttingDown reports whether fx began stopping the app.
+func (s *State) ShuttingDown() bool { return s.shuttingDown.Load() }
+
+func (s *State) InFlight() int64 { return s.inflight.Load() }
+
+// Begin marks a request as in flight; call the returned func when it ends.
+func (s *State) Begin() func() {
+	s.inflight.Add(1)
+	return func() { s.inflight.Add(-1) }
+}
+
+// RegisterHooks must be the last fx.Invoke: its OnStart runs after every
+// other hook, and its OnStop runs first, flipping readiness to 503 and
+// waiting SHUTDOWN_DRAIN_DELAY so load balancers stop routing before the
+// HTTP server shuts down.
+func RegisterHooks(lc fx.Lifecycle, s *State, cfg *config.Config, log *zap.Logger) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			s.started.Store(true)
+			log.Info("startup complete")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			s.shuttingDown.Store(true)
+			if cfg.ShutdownDrainDelay <= 0 {
+				return nil
+			}
+			log.Info("shutdown started, draining traffic",
+				zap.Duration("delay", cfg.ShutdownDrainDelay),
+				zap.Int64("inflight", s.InFlight()),
+			)
+			select {
+			case <-time.After(cfg.ShutdownDrainDelay):
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+}
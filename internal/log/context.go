@@ -0,0 +1,56 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	requestIDKey struct{}
+	loggerKey    struct{}
+)
+
+// WithRequestID stores the request ID so code below the HTTP layer (Redis,
+// DB, jobs) can put it in its logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID stored by WithRequestID, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithLogger stores a request-scoped logger; see FromContext.
+func WithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored by WithLogger (already carrying
+// request_id, trace_id, route, ...). Without one it falls back to the
+// global logger with whatever correlation fields ctx has, so it is safe to
+// call from any layer, including jobs and background loops.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return zap.L().With(Fields(ctx)...)
+}
+
+// Fields returns request_id, trace_id and span_id when ctx carries them.
+func Fields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if id := RequestID(ctx); id != "" {
+		fields = append(fields, zap.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return fields
+}
@@ -0,0 +1,234 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"microseed/pkg/backoff"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type JetStreamOptions struct {
+	Stream     string
+	Subjects   []string
+	Durable    string // prefix for durable consumer names
+	MaxDeliver int
+	AckWait    time.Duration
+}
+
+// JetStream publishes to and consumes from a single JetStream stream. It
+// takes an existing connection so it can run against an embedded server.
+type JetStream struct {
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	opts   JetStreamOptions
+	log    *zap.Logger
+	tracer trace.Tracer
+}
+
+func NewJetStream(nc *nats.Conn, opts JetStreamOptions, log *zap.Logger) (*JetStream, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+	if opts.AckWait <= 0 {
+		opts.AckWait = 30 * time.Second
+	}
+	return &JetStream{
+		nc:     nc,
+		js:     js,
+		opts:   opts,
+		log:    log.Named("messaging"),
+		tracer: otel.Tracer("microseed/messaging"),
+	}, nil
+}
+
+// EnsureStream creates the stream or updates its subjects.
+func (j *JetStream) EnsureStream(ctx context.Context) error {
+	_, err := j.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     j.opts.Stream,
+		Subjects: j.opts.Subjects,
+	})
+	if err != nil {
+		return fmt.Errorf("messaging: ensure stream %s: %w", j.opts.Stream, err)
+	}
+	return nil
+}
+
+// Publish waits for the stream ack. The caller's trace context travels in
+// the message headers.
+func (j *JetStream) Publish(ctx context.Context, msg *Message) error {
+	ctx, span := j.tracer.Start(ctx, "publish "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+		),
+	)
+	defer span.End()
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	nm := nats.NewMsg(msg.Subject)
+	nm.Data = msg.Data
+	for k, v := range msg.Header {
+		nm.Header.Set(k, v)
+	}
+	for k, v := range carrier {
+		nm.Header.Set(k, v)
+	}
+
+	ack, err := j.js.PublishMsg(ctx, nm)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetAttributes(attribute.Int64("messaging.nats.sequence", int64(ack.Sequence)))
+	return nil
+}
+
+// Subscribe binds h to the durable consumer "<durable>_<name>", so
+// replicas share the work and unacked messages survive restarts.
+func (j *JetStream) Subscribe(ctx context.Context, h Handler) (Subscription, error) {
+	durable := durableName(j.opts.Durable + "_" + h.Name())
+	cons, err := j.js.CreateOrUpdateConsumer(ctx, j.opts.Stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: h.Subject(),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       j.opts.AckWait,
+		MaxDeliver:    j.opts.MaxDeliver,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("messaging: consumer %s: %w", durable, err)
+	}
+
+	cc, err := cons.Consume(func(m jetstream.Msg) {
+		j.process(durable, h, m)
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		if !errors.Is(err, jetstream.ErrConnectionClosed) {
+			j.log.Warn("consume error", zap.String("consumer", durable), zap.Error(err))
+		}
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("messaging: consume %s: %w", durable, err)
+	}
+	return subscription{cc}, nil
+}
+
+func (j *JetStream) process(durable string, h Handler, m jetstream.Msg) {
+	msg := &Message{
+		Subject:    m.Subject(),
+		Data:       m.Data(),
+		Header:     make(map[string]string, len(m.Headers())),
+		Deliveries: 1,
+		acker:      m,
+	}
+	for k := range m.Headers() {
+		msg.Header[k] = m.Headers().Get(k)
+	}
+	if md, err := m.Metadata(); err == nil {
+		msg.Deliveries = md.NumDelivered
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(msg.Header))
+	ctx, span := j.tracer.Start(ctx, "consume "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+			attribute.String("messaging.consumer.group.name", durable),
+			attribute.Int64("messaging.delivery_count", int64(msg.Deliveries)),
+		),
+	)
+	defer span.End()
+
+	err := safeHandle(ctx, h, msg)
+	if err == nil {
+		if err := msg.Ack(); err != nil {
+			j.log.Error("ack failed", zap.String("subject", msg.Subject), zap.Error(err))
+		}
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	fields := []zap.Field{
+		zap.String("consumer", durable),
+		zap.String("subject", msg.Subject),
+		zap.Uint64("deliveries", msg.Deliveries),
+		zap.Error(err),
+	}
+	if j.opts.MaxDeliver > 0 && msg.Deliveries >= uint64(j.opts.MaxDeliver) {
+		j.log.Error("handler failed, giving up", fields...)
+		_ = msg.Term()
+		return
+	}
+	j.log.Warn("handler failed", fields...)
+	if err := msg.Nak(backoff.Exponential(int(msg.Deliveries), time.Second, time.Minute)); err != nil {
+		j.log.Error("nak failed", zap.String("subject", msg.Subject), zap.Error(err))
+	}
+}
+
+// Close drains the connection: subscriptions finish their buffered
+// messages and pending publishes are flushed before it closes.
+func (j *JetStream) Close(ctx context.Context) error {
+	if j.nc.IsClosed() {
+		return nil
+	}
+	if err := j.nc.Drain(); err != nil {
+		return err
+	}
+	t := time.NewTicker(20 * time.Millisecond)
+	defer t.Stop()
+	for !j.nc.IsClosed() {
+		select {
+		case <-ctx.Done():
+			j.nc.Close()
+			return fmt.Errorf("messaging: drain interrupted: %w", ctx.Err())
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+type subscription struct {
+	cc jetstream.ConsumeContext
+}
+
+func (s subscription) Drain(ctx context.Context) error {
+	s.cc.Drain()
+	select {
+	case <-s.cc.Closed():
+		return nil
+	case <-ctx.Done():
+		s.cc.Stop()
+		return fmt.Errorf("messaging: drain interrupted: %w", ctx.Err())
+	}
+}
+
+func safeHandle(ctx context.Context, h Handler, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h.Handle(ctx, msg)
+}
+
+// durableName replaces characters NATS does not allow in consumer names.
+func durableName(s string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(s)
+}
@@ -0,0 +1,161 @@
+//go:build integration
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+// newTestJetStream connects to the JetStream server at NATS_URL (skipping
+// when unset) and uses a fresh stream over a per-test subject prefix.
+func newTestJetStream(t *testing.T, maxDeliver int) (*JetStream, string) {
+	t.Helper()
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		t.Skip("NATS_URL not set")
+	}
+	nc, err := nats.Connect(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := strings.ReplaceAll(uuid.NewString(), "-", "")
+	prefix := "test" + id
+	js, err := NewJetStream(nc, JetStreamOptions{
+		Stream:     "TEST_" + id,
+		Subjects:   []string{prefix + ".>"},
+		Durable:    "microseed",
+		MaxDeliver: maxDeliver,
+		AckWait:    5 * time.Second,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = js.Close(ctx)
+	})
+	if err := js.EnsureStream(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if jsc, err := jetstream.New(nc); err == nil {
+			_ = jsc.DeleteStream(context.Background(), "TEST_"+id)
+		}
+	})
+	return js, prefix
+}
+
+func subscribe(t *testing.T, js *JetStream, h Handler) {
+	t.Helper()
+	sub, err := js.Subscribe(context.Background(), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := sub.Drain(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestJetStreamPublishConsume(t *testing.T) {
+	js, prefix := newTestJetStream(t, 3)
+	got := make(chan *Message, 1)
+	subscribe(t, js, HandlerFunc("orders", prefix+".orders", func(ctx context.Context, msg *Message) error {
+		got <- msg
+		return nil
+	}))
+
+	err := js.Publish(context.Background(), &Message{
+		Subject: prefix + ".orders",
+		Data:    []byte(`{"id":1}`),
+		Header:  map[string]string{"Outbox-Key": "k1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-got:
+		if string(m.Data) != `{"id":1}` || m.Header["Outbox-Key"] != "k1" || m.Deliveries != 1 {
+			t.Errorf("got %+v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered")
+	}
+}
+
+func TestJetStreamNakRedeliversThenTerms(t *testing.T) {
+	js, prefix := newTestJetStream(t, 2)
+	var mu sync.Mutex
+	var deliveries []uint64
+	done := make(chan struct{})
+	subscribe(t, js, HandlerFunc("flaky", prefix+".flaky", func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries = append(deliveries, msg.Deliveries)
+		if len(deliveries) == 2 {
+			close(done)
+		}
+		return errors.New("boom")
+	}))
+
+	if err := js.Publish(context.Background(), &Message{Subject: prefix + ".flaky", Data: []byte("x")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+	// terminated after MaxDeliver: no third delivery
+	time.Sleep(2500 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveries) != 2 || deliveries[0] != 1 || deliveries[1] != 2 {
+		t.Errorf("deliveries = %v, want [1 2]", deliveries)
+	}
+}
+
+func TestJetStreamHandlerSettlesItself(t *testing.T) {
+	js, prefix := newTestJetStream(t, 3)
+	var calls atomic.Int32
+	done := make(chan struct{})
+	subscribe(t, js, HandlerFunc("manual", prefix+".manual", func(ctx context.Context, msg *Message) error {
+		if calls.Add(1) == 1 {
+			defer close(done)
+		}
+		if err := msg.Ack(); err != nil {
+			return err
+		}
+		// already acked: the consumer's nak must be a no-op
+		return errors.New("after ack")
+	}))
+
+	if err := js.Publish(context.Background(), &Message{Subject: prefix + ".manual", Data: []byte("x")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered")
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
@@ -0,0 +1,105 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var ErrDisabled = errors.New("messaging: disabled (MESSAGING_DRIVER=none)")
+
+// Message is both what gets published and what a Handler receives. On the
+// receiving side Deliveries counts redeliveries (1 on first delivery) and
+// Ack/Nak/Term settle the message with the broker.
+type Message struct {
+	Subject string
+	Data    []byte
+	Header  map[string]string
+
+	Deliveries uint64
+
+	acker   acker
+	settled atomic.Bool // the handler and the consumer may both settle
+}
+
+type acker interface {
+	Ack() error
+	NakWithDelay(delay time.Duration) error
+	Term() error
+}
+
+// Ack confirms the message. Handlers normally just return nil instead.
+func (m *Message) Ack() error {
+	if m.acker == nil || !m.settled.CompareAndSwap(false, true) {
+		return nil
+	}
+	return m.acker.Ack()
+}
+
+// Nak asks for redelivery after delay (0 = immediately).
+func (m *Message) Nak(delay time.Duration) error {
+	if m.acker == nil || !m.settled.CompareAndSwap(false, true) {
+		return nil
+	}
+	return m.acker.NakWithDelay(delay)
+}
+
+// Term drops the message: it will not be redelivered.
+func (m *Message) Term() error {
+	if m.acker == nil || !m.settled.CompareAndSwap(false, true) {
+		return nil
+	}
+	return m.acker.Term()
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// Handler consumes one subject through a durable consumer named after it.
+// Returning nil acks the message, an error naks it with backoff; handlers
+// may also settle the message themselves.
+type Handler interface {
+	Name() string
+	Subject() string
+	Handle(ctx context.Context, msg *Message) error
+}
+
+type Subscription interface {
+	// Drain stops fetching and waits for the in-flight message.
+	Drain(ctx context.Context) error
+}
+
+type Subscriber interface {
+	Subscribe(ctx context.Context, h Handler) (Subscription, error)
+}
+
+// Client is a connected broker; Close drains the connection.
+type Client interface {
+	Publisher
+	Subscriber
+	Close(ctx context.Context) error
+}
+
+type handlerFunc struct {
+	name, subject string
+	fn            func(ctx context.Context, msg *Message) error
+}
+
+func (h handlerFunc) Name() string                                   { return h.name }
+func (h handlerFunc) Subject() string                                { return h.subject }
+func (h handlerFunc) Handle(ctx context.Context, msg *Message) error { return h.fn(ctx, msg) }
+
+func HandlerFunc(name, subject string, fn func(ctx context.Context, msg *Message) error) Handler {
+	return handlerFunc{name: name, subject: subject, fn: fn}
+}
+
+// Disabled is used when MESSAGING_DRIVER=none.
+type Disabled struct{}
+
+func (Disabled) Publish(context.Context, *Message) error { return ErrDisabled }
+func (Disabled) Subscribe(context.Context, Handler) (Subscription, error) {
+	return nil, ErrDisabled
+}
+func (Disabled) Close(context.Context) error { return nil }
@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingAcker struct{ acks, naks, terms atomic.Int32 }
+
+func (a *countingAcker) Ack() error                       { a.acks.Add(1); return nil }
+func (a *countingAcker) NakWithDelay(time.Duration) error { a.naks.Add(1); return nil }
+func (a *countingAcker) Term() error                      { a.terms.Add(1); return nil }
+
+func TestMessageSettlesOnce(t *testing.T) {
+	a := &countingAcker{}
+	m := &Message{acker: a}
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch i % 3 {
+			case 0:
+				_ = m.Ack()
+			case 1:
+				_ = m.Nak(0)
+			default:
+				_ = m.Term()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := a.acks.Load() + a.naks.Load() + a.terms.Load(); n != 1 {
+		t.Errorf("settled %d times, want 1", n)
+	}
+}
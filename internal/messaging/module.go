@@ -0,0 +1,129 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"microseed/internal/config"
+
+	"github.com/nats-io/nats.go"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module provides the Client and Publisher selected by MESSAGING_DRIVER.
+var Module = fx.Options(
+	fx.Provide(New, func(c Client) Publisher { return c }),
+	fx.Invoke(RegisterHooks),
+)
+
+// ConsumerModule subscribes handlers contributed with fx.ResultTags(`group:"messaging"`).
+var ConsumerModule = fx.Options(
+	fx.Invoke(RegisterConsumers),
+)
+
+func New(cfg *config.Config, log *zap.Logger) (Client, error) {
+	switch cfg.MessagingDriver {
+	case "", "none":
+		return Disabled{}, nil
+	case "nats":
+		nc, err := nats.Connect(cfg.NATSURL,
+			nats.Name(cfg.AppName),
+			nats.RetryOnFailedConnect(true),
+			nats.MaxReconnects(-1),
+			nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+				if err != nil {
+					log.Warn("nats disconnected", zap.Error(err))
+				}
+			}),
+			nats.ReconnectHandler(func(nc *nats.Conn) {
+				log.Info("nats reconnected", zap.String("url", nc.ConnectedUrl()))
+			}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("messaging: connect %s: %w", cfg.NATSURL, err)
+		}
+		return NewJetStream(nc, JetStreamOptions{
+			Stream:     cfg.NATSStream,
+			Subjects:   cfg.NATSSubjects,
+			Durable:    cfg.NATSDurable,
+			MaxDeliver: cfg.NATSMaxDeliver,
+			AckWait:    cfg.NATSAckWait,
+		}, log)
+	default:
+		return nil, fmt.Errorf("messaging: unknown driver %q", cfg.MessagingDriver)
+	}
+}
+
+func RegisterHooks(lc fx.Lifecycle, c Client, cfg *config.Config, log *zap.Logger) {
+	js, ok := c.(*JetStream)
+	if !ok {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := js.EnsureStream(ctx); err != nil {
+				return err
+			}
+			log.Info("nats jetstream ready",
+				zap.String("url", cfg.NATSURL),
+				zap.String("stream", cfg.NATSStream),
+				zap.Strings("subjects", cfg.NATSSubjects),
+			)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopCtx, cancel := context.WithTimeout(ctx, cfg.GracefulTimeout)
+			defer cancel()
+			log.Info("draining nats connection")
+			return js.Close(stopCtx)
+		},
+	})
+}
+
+type consumersIn struct {
+	fx.In
+
+	LC       fx.Lifecycle
+	Client   Client
+	Cfg      *config.Config
+	Log      *zap.Logger
+	Handlers []Handler `group:"messaging"`
+}
+
+// RegisterConsumers subscribes on start and drains the subscriptions on
+// stop, before the connection itself is drained.
+func RegisterConsumers(in consumersIn) {
+	if len(in.Handlers) == 0 {
+		return
+	}
+	if _, ok := in.Client.(Disabled); ok {
+		in.Log.Warn("messaging handlers registered but MESSAGING_DRIVER=none", zap.Int("handlers", len(in.Handlers)))
+		return
+	}
+	var subs []Subscription
+	in.LC.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			for _, h := range in.Handlers {
+				sub, err := in.Client.Subscribe(ctx, h)
+				if err != nil {
+					return err
+				}
+				subs = append(subs, sub)
+			}
+			in.Log.Info("messaging consumers started", zap.Int("handlers", len(in.Handlers)))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopCtx, cancel := context.WithTimeout(ctx, in.Cfg.GracefulTimeout)
+			defer cancel()
+			in.Log.Info("stopping messaging consumers")
+			var errs []error
+			for _, s := range subs {
+				errs = append(errs, s.Drain(stopCtx))
+			}
+			return errors.Join(errs...)
+		},
+	})
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 
 	"microseed/internal/config"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pressly/goose/v3"
 	"go.uber.org/zap"
 
@@ -81,3 +83,18 @@ func Reset(ctx context.Context, cfg *config.Config, log *zap.Logger) error {
 	log.Info("migrations reset to version 0")
 	return nil
 }
+
+// CurrentVersion reads the applied version from goose_db_version without
+// creating the table (0 when nothing has been migrated yet).
+func CurrentVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	var v sql.NullInt64
+	err := db.QueryRowContext(ctx, `SELECT MAX(version_id) FROM goose_db_version WHERE is_applied`).Scan(&v)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" { // undefined_table
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return v.Int64, nil
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS job_runs (
+     id UUID PRIMARY KEY,
+     name TEXT NOT NULL,
+     trigger TEXT NOT NULL,
+     status TEXT NOT NULL,
+     instance TEXT NOT NULL,
+     error TEXT NOT NULL DEFAULT '',
+     started_at TIMESTAMPTZ NOT NULL,
+     finished_at TIMESTAMPTZ,
+     duration_ms BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_job_runs_name_started_at ON job_runs (name, started_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS job_runs;
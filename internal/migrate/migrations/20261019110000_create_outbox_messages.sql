@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS outbox_messages (
+     id UUID PRIMARY KEY,
+     topic TEXT NOT NULL,
+     key TEXT NOT NULL DEFAULT '',
+     payload JSONB NOT NULL,
+     headers JSONB NOT NULL DEFAULT '{}',
+     attempts INT NOT NULL DEFAULT 0,
+     last_error TEXT NOT NULL DEFAULT '',
+     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+     next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+     delivered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages (next_attempt_at) WHERE delivered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_delivered ON outbox_messages (delivered_at) WHERE delivered_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_messages;
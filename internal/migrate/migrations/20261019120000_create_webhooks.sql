@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+     id UUID PRIMARY KEY,
+     url TEXT NOT NULL,
+     secret TEXT NOT NULL,
+     events JSONB NOT NULL DEFAULT '[]',
+     description TEXT NOT NULL DEFAULT '',
+     active BOOLEAN NOT NULL DEFAULT TRUE,
+     failure_count INT NOT NULL DEFAULT 0,
+     disabled_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+     id UUID PRIMARY KEY,
+     subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+     event_id UUID NOT NULL,
+     event_type TEXT NOT NULL,
+     request JSONB NOT NULL,
+     attempt INT NOT NULL,
+     status_code INT NOT NULL DEFAULT 0,
+     response TEXT NOT NULL DEFAULT '',
+     error TEXT NOT NULL DEFAULT '',
+     success BOOLEAN NOT NULL DEFAULT FALSE,
+     duration_ms BIGINT NOT NULL DEFAULT 0,
+     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
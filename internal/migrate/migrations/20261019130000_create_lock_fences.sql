@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS lock_fences (
+     key TEXT PRIMARY KEY,
+     token BIGINT NOT NULL,
+     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS lock_fences;
@@ -2,24 +2,37 @@ package obs
 
 import (
 	"context"
+	"errors"
 
 	"microseed/internal/config"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.uber.org/fx"
 )
 
 type OTel struct {
 	TP *sdktrace.TracerProvider
+	MP *sdkmetric.MeterProvider
 }
 
 func New(cfg *config.Config) (*OTel, error) {
+	// W3C traceparent + baggage (HTTP headers, job payloads)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
 	if cfg.OTLPEndpoint == "" {
-		otel.SetTracerProvider(sdktrace.NewTracerProvider()) // no-op
-		return &OTel{TP: nil}, nil
+		// no-op tracer; the global meter provider stays no-op as well
+		otel.SetTracerProvider(sdktrace.NewTracerProvider())
+		return &OTel{}, nil
 	}
 	exp, err := otlptracehttp.New(context.Background(),
 		otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint),
@@ -38,5 +51,32 @@ func New(cfg *config.Config) (*OTel, error) {
 		sdktrace.WithResource(rsrc),
 	)
 	otel.SetTracerProvider(tp)
-	return &OTel{TP: tp}, nil
+
+	mexp, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpointURL(cfg.OTLPEndpoint),
+	)
+	if err != nil {
+		return nil, err
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(mexp)),
+		sdkmetric.WithResource(rsrc),
+	)
+	otel.SetMeterProvider(mp)
+	return &OTel{TP: tp, MP: mp}, nil
+}
+
+func RegisterHooks(lc fx.Lifecycle, o *OTel) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			var err error
+			if o.TP != nil {
+				err = o.TP.Shutdown(ctx)
+			}
+			if o.MP != nil {
+				err = errors.Join(err, o.MP.Shutdown(ctx))
+			}
+			return err
+		},
+	})
 }
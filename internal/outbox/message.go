@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+)
+
+type Message struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	Topic         string            `gorm:"not null" json:"topic"`
+	Key           string            `gorm:"not null;default:''" json:"key,omitempty"`
+	Payload       json.RawMessage   `gorm:"type:jsonb;serializer:json;not null" json:"payload"`
+	Headers       map[string]string `gorm:"type:jsonb;serializer:json;not null" json:"headers,omitempty"`
+	Attempts      int               `gorm:"not null;default:0" json:"-"`
+	LastError     string            `gorm:"not null;default:''" json:"-"`
+	CreatedAt     time.Time         `gorm:"not null" json:"created_at"`
+	NextAttemptAt time.Time         `gorm:"not null" json:"-"`
+	DeliveredAt   *time.Time        `json:"-"`
+}
+
+func (Message) TableName() string { return "outbox_messages" }
+
+// Add stores a message using tx, so it commits or rolls back together with
+// the entity change. The caller's trace context is kept in the headers.
+func Add(tx *gorm.DB, topic, key string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	headers := propagation.MapCarrier{}
+	if ctx := tx.Statement.Context; ctx != nil {
+		otel.GetTextMapPropagator().Inject(ctx, headers)
+	}
+	now := time.Now()
+	return tx.Create(&Message{
+		ID:            uuid.New(),
+		Topic:         topic,
+		Key:           key,
+		Payload:       raw,
+		Headers:       headers,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}).Error
+}
@@ -0,0 +1,9 @@
+package outbox
+
+import "go.uber.org/fx"
+
+// Module runs the relay; it can be included in both serve and worker.
+var Module = fx.Options(
+	fx.Provide(NewPublisher, NewRelay),
+	fx.Invoke(RegisterHooks),
+)
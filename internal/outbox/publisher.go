@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/events"
+	"microseed/internal/httpx"
+	"microseed/internal/messaging"
+	"microseed/internal/stream"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+func NewPublisher(cfg *config.Config, bus events.Bus, producer *stream.Producer, broker messaging.Publisher) (Publisher, error) {
+	switch cfg.OutboxPublisher {
+	case "", "local":
+		return NewLocalPublisher(bus), nil
+	case "redis":
+		return NewStreamPublisher(producer, cfg.AppName+":outbox:"), nil
+	case "nats":
+		if cfg.MessagingDriver != "nats" {
+			return nil, errors.New("outbox: the nats publisher requires MESSAGING_DRIVER=nats")
+		}
+		return NewBrokerPublisher(broker, cfg.AppName+"."), nil
+	case "webhook":
+		if cfg.OutboxWebhookURL == "" {
+			return nil, errors.New("outbox: OUTBOX_WEBHOOK_URL is required for the webhook publisher")
+		}
+		return NewWebhookPublisher(cfg.OutboxWebhookURL, &http.Client{Timeout: 10 * time.Second, Transport: httpx.Transport(nil)}), nil
+	default:
+		return nil, fmt.Errorf("outbox: unknown publisher %q", cfg.OutboxPublisher)
+	}
+}
+
+// Event carries a relayed message on the in-process bus (OUTBOX_PUBLISHER=local).
+// Subscribe with events.On[outbox.Event] and filter on Topic; an error from
+// a sync subscriber leaves the message undelivered for a retry.
+type Event struct {
+	*Message
+}
+
+func (Event) EventName() string { return "outbox.message" }
+
+// LocalPublisher forwards messages to the events.Bus as Event.
+type LocalPublisher struct {
+	bus events.Bus
+}
+
+func NewLocalPublisher(bus events.Bus) *LocalPublisher {
+	return &LocalPublisher{bus: bus}
+}
+
+func (p *LocalPublisher) Publish(ctx context.Context, msg *Message) error {
+	return p.bus.Publish(ctx, Event{Message: msg})
+}
+
+// StreamPublisher appends messages to the Redis stream <prefix><topic>.
+type StreamPublisher struct {
+	producer *stream.Producer
+	prefix   string
+}
+
+func NewStreamPublisher(producer *stream.Producer, prefix string) *StreamPublisher {
+	return &StreamPublisher{producer: producer, prefix: prefix}
+}
+
+func (p *StreamPublisher) Publish(ctx context.Context, msg *Message) error {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = p.producer.Publish(ctx, p.prefix+msg.Topic, map[string]any{
+		"id":      msg.ID.String(),
+		"key":     msg.Key,
+		"payload": string(msg.Payload),
+		"headers": string(headers),
+	})
+	return err
+}
+
+// BrokerPublisher publishes to the subject <prefix><topic> on the message broker.
+type BrokerPublisher struct {
+	broker messaging.Publisher
+	prefix string
+}
+
+func NewBrokerPublisher(broker messaging.Publisher, prefix string) *BrokerPublisher {
+	return &BrokerPublisher{broker: broker, prefix: prefix}
+}
+
+func (p *BrokerPublisher) Publish(ctx context.Context, msg *Message) error {
+	header := make(map[string]string, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		header[k] = v
+	}
+	// JetStream dedupes on Nats-Msg-Id within the stream's duplicate window
+	header["Nats-Msg-Id"] = msg.ID.String()
+	header["Outbox-Key"] = msg.Key
+	return p.broker.Publish(ctx, &messaging.Message{
+		Subject: p.prefix + msg.Topic,
+		Data:    msg.Payload,
+		Header:  header,
+	})
+}
+
+// WebhookPublisher POSTs each message as JSON; any non-2xx response is a failure.
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, client *http.Client) *WebhookPublisher {
+	return &WebhookPublisher{url: url, client: client}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", msg.ID.String())
+	for k, v := range msg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("outbox: webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/pkg/backoff"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const cleanupEvery = time.Hour
+
+// Relay polls undelivered messages and hands them to the Publisher.
+// Rows are claimed with FOR UPDATE SKIP LOCKED, so several replicas can
+// run a relay concurrently without publishing a message twice.
+type Relay struct {
+	db        *gorm.DB
+	pub       Publisher
+	log       *zap.Logger
+	interval  time.Duration
+	batch     int
+	retention time.Duration
+
+	lagSeconds atomic.Int64
+	published  metric.Int64Counter
+	failures   metric.Int64Counter
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRelay(db *gorm.DB, pub Publisher, cfg *config.Config, log *zap.Logger) (*Relay, error) {
+	r := &Relay{
+		db:        db,
+		pub:       pub,
+		log:       log.Named("outbox"),
+		interval:  cfg.OutboxPollInterval,
+		batch:     cfg.OutboxBatchSize,
+		retention: cfg.OutboxRetention,
+	}
+	if r.batch <= 0 {
+		r.batch = 100
+	}
+
+	meter := otel.Meter("microseed/outbox")
+	var err error
+	if r.published, err = meter.Int64Counter("outbox.published",
+		metric.WithDescription("Outbox messages delivered to the publisher")); err != nil {
+		return nil, err
+	}
+	if r.failures, err = meter.Int64Counter("outbox.failures",
+		metric.WithDescription("Failed outbox publish attempts")); err != nil {
+		return nil, err
+	}
+	_, err = meter.Int64ObservableGauge("outbox.lag",
+		metric.WithDescription("Age of the oldest undelivered outbox message"),
+		metric.WithUnit("s"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(r.lagSeconds.Load())
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Relay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.loop(ctx)
+	}()
+}
+
+func (r *Relay) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Relay) loop(ctx context.Context) {
+	var lastCleanup time.Time
+	for {
+		n, err := r.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Error("poll failed", zap.Error(err))
+		}
+		if time.Since(lastCleanup) >= cleanupEvery {
+			r.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+		r.updateLag(ctx)
+
+		wait := r.interval
+		if n == r.batch {
+			wait = 0 // backlog: keep draining
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Poll publishes one batch of due messages and returns how many were handled.
+//
+// Rows are claimed in a short transaction that pushes next_attempt_at out
+// by claimFor, then published outside of it: a slow publisher holds no
+// row locks, and each row is settled on its own. A relay that dies
+// mid-batch leaves its claims to expire, so delivery is at least once.
+func (r *Relay) Poll(ctx context.Context) (int, error) {
+	msgs, err := r.claim(ctx)
+	if err != nil || len(msgs) == 0 {
+		return 0, err
+	}
+	claimed := time.Now()
+
+	var handled int
+	for i := range msgs {
+		if ctx.Err() != nil || time.Since(claimed) > claimFor/2 {
+			// hand the rest back rather than publish on a claim about to expire
+			return handled, r.unclaim(context.WithoutCancel(ctx), msgs[i:])
+		}
+		if err := r.publish(ctx, &msgs[i]); err != nil {
+			return handled, errors.Join(err, r.unclaim(context.WithoutCancel(ctx), msgs[i+1:]))
+		}
+		handled++
+	}
+	return handled, nil
+}
+
+// claimFor is how long a claimed row stays invisible to other relays.
+const claimFor = 5 * time.Minute
+
+func (r *Relay) claim(ctx context.Context) ([]Message, error) {
+	var msgs []Message
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("created_at").
+			Limit(r.batch).
+			Find(&msgs).Error
+		if err != nil || len(msgs) == 0 {
+			return err
+		}
+		return tx.Model(&Message{}).
+			Where("id IN ?", ids(msgs)).
+			Update("next_attempt_at", time.Now().Add(claimFor)).Error
+	})
+	return msgs, err
+}
+
+func (r *Relay) unclaim(ctx context.Context, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&Message{}).
+		Where("id IN ?", ids(msgs)).
+		Update("next_attempt_at", time.Now()).Error
+}
+
+// publish sends one claimed message and records the outcome on its row.
+func (r *Relay) publish(ctx context.Context, m *Message) error {
+	pctx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(m.Headers))
+	topic := metric.WithAttributes(attribute.String("topic", m.Topic))
+	// the outcome must be recorded even when shutdown cancels ctx
+	db := r.db.WithContext(context.WithoutCancel(ctx)).Model(m)
+
+	if perr := r.pub.Publish(pctx, m); perr != nil {
+		m.Attempts++
+		next := time.Now().Add(backoff.Exponential(m.Attempts, time.Second, time.Hour))
+		r.failures.Add(ctx, 1, topic)
+		r.log.Warn("publish failed",
+			zap.String("id", m.ID.String()),
+			zap.String("topic", m.Topic),
+			zap.Int("attempts", m.Attempts),
+			zap.Time("next_attempt_at", next),
+			zap.Error(perr),
+		)
+		return db.Updates(map[string]any{
+			"attempts":        m.Attempts,
+			"last_error":      perr.Error(),
+			"next_attempt_at": next,
+		}).Error
+	}
+	r.published.Add(ctx, 1, topic)
+	return db.Update("delivered_at", time.Now()).Error
+}
+
+func ids(msgs []Message) []uuid.UUID {
+	out := make([]uuid.UUID, len(msgs))
+	for i := range msgs {
+		out[i] = msgs[i].ID
+	}
+	return out
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	res := r.db.WithContext(ctx).
+		Where("delivered_at IS NOT NULL AND delivered_at < ?", time.Now().Add(-r.retention)).
+		Delete(&Message{})
+	if res.Error != nil {
+		r.log.Error("retention cleanup failed", zap.Error(res.Error))
+		return
+	}
+	if res.RowsAffected > 0 {
+		r.log.Info("retention cleanup", zap.Int64("deleted", res.RowsAffected))
+	}
+}
+
+func (r *Relay) updateLag(ctx context.Context) {
+	var oldest sql.NullTime
+	err := r.db.WithContext(ctx).Model(&Message{}).
+		Where("delivered_at IS NULL").
+		Select("MIN(created_at)").
+		Row().Scan(&oldest)
+	if err != nil {
+		return
+	}
+	if !oldest.Valid {
+		r.lagSeconds.Store(0)
+		return
+	}
+	r.lagSeconds.Store(int64(time.Since(oldest.Time).Seconds()))
+}
+
+func RegisterHooks(lc fx.Lifecycle, r *Relay, cfg *config.Config, log *zap.Logger) {
+	if !cfg.OutboxRelayEnabled {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			r.Start()
+			log.Info("outbox relay started", zap.Duration("interval", r.interval))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info("stopping outbox relay")
+			return r.Stop(ctx)
+		},
+	})
+}
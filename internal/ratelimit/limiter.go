@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"microseed/internal/cache"
+	"microseed/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Algorithm string
+
+const (
+	TokenBucket   Algorithm = "token_bucket"
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Limit allows Requests per Per. With TokenBucket the bucket holds
+// Requests tokens (the burst) and refills evenly over Per; SlidingWindow
+// weights the previous window by how much of it still overlaps.
+type Limit struct {
+	Requests  int
+	Per       time.Duration
+	Algorithm Algorithm
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // when denied: wait this long before retrying
+	Reset      time.Duration // until the quota is fully available again
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// New returns the limiter for RATE_LIMIT_BACKEND. The Redis limiter falls
+// back to an in-memory one while Redis is unavailable.
+func New(cfg *config.Config, rdb redis.UniversalClient, avail *cache.Availability) (Limiter, error) {
+	switch Algorithm(cfg.RateLimitAlgorithm) {
+	case "", TokenBucket, SlidingWindow:
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown algorithm %q", cfg.RateLimitAlgorithm)
+	}
+	switch cfg.RateLimitBackend {
+	case "", "redis":
+		return NewRedis(rdb, avail, cfg.AppName+":ratelimit:", NewMemory()), nil
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown backend %q", cfg.RateLimitBackend)
+	}
+}
+
+// bucket is the state of one key; both algorithms share it so the memory
+// limiter mirrors the Lua scripts.
+type bucket struct {
+	// token bucket
+	tokens float64
+	ts     int64 // ms
+	// sliding window
+	win, cur, prev int64
+
+	expires int64 // ms
+}
+
+func (b *bucket) take(l Limit, now int64) Result {
+	if l.Algorithm == SlidingWindow {
+		return b.slidingWindow(l, now)
+	}
+	return b.tokenBucket(l, now)
+}
+
+func (b *bucket) tokenBucket(l Limit, now int64) Result {
+	rate := float64(l.Requests)
+	refill := rate / float64(l.Per.Milliseconds()) // tokens per ms
+	if b.ts == 0 {
+		b.tokens, b.ts = rate, now
+	}
+	b.tokens = math.Min(rate, b.tokens+float64(max(0, now-b.ts))*refill)
+	b.ts = now
+	b.expires = now + l.Per.Milliseconds()
+
+	res := Result{Limit: l.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = msDur(math.Ceil((1 - b.tokens) / refill))
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = msDur(math.Ceil((rate - b.tokens) / refill))
+	return res
+}
+
+func (b *bucket) slidingWindow(l Limit, now int64) Result {
+	period := l.Per.Milliseconds()
+	win := now / period
+	switch {
+	case b.win == win:
+	case b.win == win-1:
+		b.prev, b.cur = b.cur, 0
+	default:
+		b.prev, b.cur = 0, 0
+	}
+	b.win = win
+	b.expires = now + 2*period
+
+	elapsed := now - win*period
+	weight := float64(period-elapsed) / float64(period)
+	est := float64(b.prev)*weight + float64(b.cur)
+	rate := float64(l.Requests)
+	res := Result{Limit: l.Requests, Reset: time.Duration(period-elapsed) * time.Millisecond}
+	if est+1 > rate {
+		res.RetryAfter = res.Reset
+		if b.prev > 0 && float64(b.cur)+1 <= rate {
+			need := (rate - float64(b.cur) - 1) / float64(b.prev)
+			res.RetryAfter = msDur(math.Ceil((1-need)*float64(period) - float64(elapsed)))
+		}
+		return res
+	}
+	b.cur++
+	res.Allowed = true
+	res.Remaining = int(math.Floor(rate - est - 1))
+	return res
+}
+
+func msDur(ms float64) time.Duration {
+	return time.Duration(max(0, ms)) * time.Millisecond
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepEvery = time.Minute
+
+// Memory keeps buckets in process: limits are per instance. Idle buckets
+// are swept once a minute.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}, lastSweep: time.Now(), now: time.Now}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, l Limit) (Result, error) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepEvery {
+		m.sweep(now.UnixMilli())
+		m.lastSweep = now
+	}
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{}
+		m.buckets[key] = b
+	}
+	return b.take(l, now.UnixMilli()), nil
+}
+
+func (m *Memory) sweep(now int64) {
+	for k, b := range m.buckets {
+		if b.expires <= now {
+			delete(m.buckets, k)
+		}
+	}
+}
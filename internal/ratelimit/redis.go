@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"microseed/internal/cache"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Both scripts keep one hash per key (cluster safe) and use the Redis
+// clock, so every replica agrees. They return
+// {allowed, remaining, retry_after_ms, reset_ms}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local s = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(s[1]) or rate
+local ts = tonumber(s[2]) or now
+local refill = rate / period
+tokens = math.min(rate, tokens + math.max(0, now - ts) * refill)
+local allowed, retry = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / refill)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, math.floor(tokens), retry, math.ceil((rate - tokens) / refill)}
+`)
+
+var slidingWindowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local win = math.floor(now / period)
+local s = redis.call('HMGET', KEYS[1], 'win', 'cur', 'prev')
+local w = tonumber(s[1])
+local cur = tonumber(s[2]) or 0
+local prev = tonumber(s[3]) or 0
+if w == win - 1 then
+  prev, cur = cur, 0
+elseif w ~= win then
+  prev, cur = 0, 0
+end
+local elapsed = now - win * period
+local est = prev * (period - elapsed) / period + cur
+local reset = period - elapsed
+local allowed, remaining, retry = 0, 0, reset
+if est + 1 > rate then
+  if prev > 0 and cur + 1 <= rate then
+    retry = math.ceil((1 - (rate - cur - 1) / prev) * period - elapsed)
+  end
+else
+  cur = cur + 1
+  allowed, remaining, retry = 1, math.floor(rate - est - 1), 0
+end
+redis.call('HSET', KEYS[1], 'win', win, 'cur', cur, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], 2 * period)
+return {allowed, remaining, retry, reset}
+`)
+
+// Redis enforces limits across all replicas. While Redis is unavailable
+// (or a call fails) it answers from the in-memory fallback, so limits
+// become per instance instead of disappearing.
+type Redis struct {
+	rdb      redis.UniversalClient
+	avail    *cache.Availability
+	prefix   string
+	fallback Limiter
+}
+
+func NewRedis(rdb redis.UniversalClient, avail *cache.Availability, prefix string, fallback Limiter) *Redis {
+	return &Redis{rdb: rdb, avail: avail, prefix: prefix, fallback: fallback}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	if !r.avail.Available() {
+		return r.fallback.Allow(ctx, key, l)
+	}
+	script := tokenBucketScript
+	if l.Algorithm == SlidingWindow {
+		script = slidingWindowScript
+	}
+	vals, err := script.Run(ctx, r.rdb, []string{r.prefix + key}, l.Requests, l.Per.Milliseconds()).Int64Slice()
+	if err != nil {
+		return r.fallback.Allow(ctx, key, l)
+	}
+	return Result{
+		Allowed:    vals[0] == 1,
+		Limit:      l.Requests,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+		Reset:      time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+
+	"microseed/internal/apperr"
+	"microseed/internal/config"
+	"microseed/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Sched *Scheduler
+	Token string
+}
+
+func NewHandler(s *Scheduler, cfg *config.Config) *Handler {
+	return &Handler{Sched: s, Token: cfg.AdminToken}
+}
+
+func (h *Handler) Register(r *gin.Engine) {
+	admin := r.Group("/admin/scheduler", httpx.AdminAuth(h.Token))
+	admin.GET("/tasks", h.list)
+	admin.GET("/tasks/:name/runs", h.runs)
+	admin.POST("/tasks/:name/run", h.trigger)
+}
+
+func (h *Handler) list(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"leader": h.Sched.IsLeader(),
+		"tasks":  h.Sched.Tasks(),
+	})
+}
+
+func (h *Handler) runs(c *gin.Context) {
+	runs, err := h.Sched.RecentRuns(c.Request.Context(), c.Param("name"), 20)
+	if err != nil {
+		httpx.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+func (h *Handler) trigger(c *gin.Context) {
+	run, err := h.Sched.Trigger(c.Request.Context(), c.Param("name"))
+	switch {
+	case errors.Is(err, ErrUnknownTask):
+		httpx.Fail(c, apperr.NotFound("unknown task"))
+	case errors.Is(err, ErrAlreadyRunning):
+		httpx.Fail(c, apperr.Conflict("task already running"))
+	case err != nil:
+		httpx.Fail(c, err)
+	default:
+		c.JSON(http.StatusAccepted, run)
+	}
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/db"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Elector decides which replica runs scheduled tasks.
+type Elector interface {
+	// TryAcquire acquires leadership or renews it when already held.
+	TryAcquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+func NewElector(cfg *config.Config, rdb redis.UniversalClient, gdb *gorm.DB) (Elector, error) {
+	key := cfg.AppName + ":scheduler:leader"
+	switch cfg.SchedulerLeader {
+	case "", "redis":
+		return NewRedisElector(rdb, key, instance, cfg.SchedulerLockTTL), nil
+	case "postgres":
+		sqlDB, err := gdb.DB()
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresElector(sqlDB, key), nil
+	default:
+		return nil, fmt.Errorf("scheduler: unknown leader backend %q", cfg.SchedulerLeader)
+	}
+}
+
+var acquireScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  redis.call('PEXPIRE', KEYS[1], ARGV[2])
+  return 1
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return 1
+end
+return 0
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisElector holds a key with a TTL; the leader renews it on every
+// TryAcquire, so a crashed leader is replaced after at most one TTL.
+type RedisElector struct {
+	rdb      redis.UniversalClient
+	key      string
+	instance string
+	ttl      time.Duration
+}
+
+func NewRedisElector(rdb redis.UniversalClient, key, instance string, ttl time.Duration) *RedisElector {
+	return &RedisElector{rdb: rdb, key: key, instance: instance, ttl: ttl}
+}
+
+func (e *RedisElector) TryAcquire(ctx context.Context) (bool, error) {
+	n, err := acquireScript.Run(ctx, e.rdb, []string{e.key}, e.instance, e.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (e *RedisElector) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, e.rdb, []string{e.key}, e.instance).Err()
+}
+
+// PostgresElector uses a session-level advisory lock held on a dedicated
+// connection; leadership ends when that connection dies.
+type PostgresElector struct {
+	db     *sql.DB
+	lockID int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+func NewPostgresElector(db *sql.DB, key string) *PostgresElector {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return &PostgresElector{db: db, lockID: int64(h.Sum64())}
+}
+
+func (e *PostgresElector) TryAcquire(ctx context.Context) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		if err := e.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// the ping may have failed on our side only: never hand a
+		// connection that could still hold the lock back to the pool
+		db.Discard(e.conn)
+		e.conn = nil
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.lockID).Scan(&ok); err != nil {
+		db.Discard(conn)
+		return false, err
+	}
+	if !ok {
+		_ = conn.Close()
+		return false, nil
+	}
+	e.conn = conn
+	return true, nil
+}
+
+func (e *PostgresElector) Release(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+	var unlocked bool
+	err := e.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", e.lockID).Scan(&unlocked)
+	if err != nil || !unlocked {
+		// closing the session is what frees the lock then
+		db.Discard(e.conn)
+		e.conn = nil
+		return err
+	}
+	err = e.conn.Close()
+	e.conn = nil
+	return err
+}
@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"microseed/internal/httpx"
+
+	"go.uber.org/fx"
+)
+
+// Module runs tasks contributed with fx.ResultTags(`group:"cron"`) on the
+// elected leader. It can be included in both serve and worker.
+var Module = fx.Options(
+	fx.Provide(New, NewElector),
+	fx.Provide(
+		fx.Annotate(
+			NewHandler,
+			fx.As(new(httpx.RouteRegistrar)),
+			fx.ResultTags(`group:"routes"`),
+		),
+	),
+	fx.Invoke(RegisterHooks),
+)
@@ -0,0 +1,393 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"microseed/internal/cache"
+	"microseed/internal/config"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownTask    = errors.New("scheduler: unknown task")
+	ErrAlreadyRunning = errors.New("scheduler: task already running")
+)
+
+const tick = time.Second
+
+type entry struct {
+	task     Task
+	schedule cron.Schedule
+	next     time.Time
+	running  bool
+}
+
+// TaskInfo is the admin view of a registered task.
+type TaskInfo struct {
+	Name    string    `json:"name"`
+	Spec    string    `json:"spec"`
+	Next    time.Time `json:"next,omitempty"`
+	Running bool      `json:"running"`
+}
+
+type Scheduler struct {
+	db       *gorm.DB
+	elector  Elector
+	locker   cache.Locker
+	lockTTL  time.Duration
+	log      *zap.Logger
+	tracer   trace.Tracer
+	instance string
+	renewal  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	leader  bool
+
+	runCtx     context.Context // parent of task runs; cancelled only when drain times out
+	cancelRuns context.CancelFunc
+	cancel     context.CancelFunc
+	loopWG     sync.WaitGroup
+	runWG      sync.WaitGroup
+}
+
+type schedulerIn struct {
+	fx.In
+
+	DB      *gorm.DB
+	Elector Elector
+	Locker  cache.Locker
+	Cfg     *config.Config
+	Log     *zap.Logger
+	Tasks   []Task `group:"cron"`
+}
+
+func New(in schedulerIn) (*Scheduler, error) {
+	s := &Scheduler{
+		db:       in.DB,
+		elector:  in.Elector,
+		locker:   in.Locker,
+		lockTTL:  in.Cfg.SchedulerLockTTL,
+		log:      in.Log.Named("scheduler"),
+		tracer:   otel.Tracer("microseed/scheduler"),
+		instance: instance,
+		renewal:  in.Cfg.SchedulerLockTTL / 3,
+		entries:  map[string]*entry{},
+	}
+	s.runCtx, s.cancelRuns = context.WithCancel(context.Background())
+	for _, t := range in.Tasks {
+		sched, err := cron.ParseStandard(t.Spec())
+		if err != nil {
+			return nil, fmt.Errorf("scheduler: task %s: %w", t.Name(), err)
+		}
+		if _, dup := s.entries[t.Name()]; dup {
+			return nil, fmt.Errorf("scheduler: duplicate task %s", t.Name())
+		}
+		s.entries[t.Name()] = &entry{task: t, schedule: sched}
+	}
+	return s, nil
+}
+
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.loopWG.Add(1)
+	go func() {
+		defer s.loopWG.Done()
+		s.loop(ctx)
+	}()
+}
+
+// Stop ends the loop, waits for running tasks and gives up leadership.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+		s.loopWG.Wait()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.runWG.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("scheduler: drain interrupted: %w", ctx.Err())
+	}
+	s.cancelRuns()
+	relCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+	defer cancel()
+	return errors.Join(err, s.elector.Release(relCtx))
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+
+	var lastRenew time.Time
+	for {
+		if time.Since(lastRenew) >= s.renewal {
+			s.campaign(ctx)
+			lastRenew = time.Now()
+		}
+		s.dispatch(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Scheduler) campaign(ctx context.Context) {
+	ok, err := s.elector.TryAcquire(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.log.Warn("leader election failed", zap.Error(err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok == s.leader {
+		return
+	}
+	s.leader = ok
+	if ok {
+		// start from now; missed ticks are not backfilled
+		now := time.Now()
+		for _, e := range s.entries {
+			e.next = e.schedule.Next(now)
+		}
+		s.log.Info("became scheduler leader", zap.String("instance", s.instance))
+	} else {
+		s.log.Info("lost scheduler leadership", zap.String("instance", s.instance))
+	}
+}
+
+func (s *Scheduler) dispatch(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.leader {
+		return
+	}
+	for _, e := range s.entries {
+		if e.next.After(now) {
+			continue
+		}
+		e.next = e.schedule.Next(now)
+		if e.running {
+			s.log.Warn("skipping tick, previous run still active", zap.String("task", e.task.Name()))
+			continue
+		}
+		s.startLocked(s.runCtx, e, TriggerSchedule, nil)
+	}
+}
+
+// Trigger runs a task immediately on this instance. Scheduled and manual
+// runs share a per-task lock, so it fails with ErrAlreadyRunning while
+// any replica (the leader included) is running the task.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*Run, error) {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrUnknownTask
+	}
+	if e.running {
+		s.mu.Unlock()
+		return nil, ErrAlreadyRunning
+	}
+	e.running = true // reserved while the lock is taken
+	s.mu.Unlock()
+
+	lease, err := s.locker.TryAcquire(ctx, runLockKey(name), s.lockTTL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.running = false
+	switch {
+	case errors.Is(err, cache.ErrNotAcquired):
+		return nil, ErrAlreadyRunning
+	case err != nil:
+		return nil, fmt.Errorf("scheduler: run lock: %w", err)
+	}
+	// keep the caller's trace, not its cancellation
+	runCtx := trace.ContextWithSpanContext(s.runCtx, trace.SpanContextFromContext(ctx))
+	return s.startLocked(runCtx, e, TriggerManual, lease), nil
+}
+
+func runLockKey(task string) string { return "scheduler:run:" + task }
+
+// startLocked runs e in the background. Without a lease it takes the run
+// lock first and skips the run when another replica holds it.
+func (s *Scheduler) startLocked(ctx context.Context, e *entry, trigger string, lease *cache.Lease) *Run {
+	run := &Run{
+		ID:        uuid.New(),
+		Name:      e.task.Name(),
+		Trigger:   trigger,
+		Status:    StatusRunning,
+		Instance:  s.instance,
+		StartedAt: time.Now(),
+	}
+	e.running = true
+	s.runWG.Add(1)
+	go func() {
+		defer s.runWG.Done()
+		if lease == nil {
+			var err error
+			if lease, err = s.locker.TryAcquire(ctx, runLockKey(run.Name), s.lockTTL); err != nil {
+				if errors.Is(err, cache.ErrNotAcquired) {
+					s.log.Warn("skipping tick, task running elsewhere", zap.String("task", run.Name))
+				} else {
+					s.log.Error("run lock failed", zap.String("task", run.Name), zap.Error(err))
+				}
+				s.mu.Lock()
+				e.running = false
+				s.mu.Unlock()
+				return
+			}
+		}
+		// stop the task when the lock is lost: another replica may take over
+		runCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lease.Done():
+				cancel()
+			case <-runCtx.Done():
+			}
+		}()
+		s.execute(runCtx, e, run)
+		cancel()
+		relCtx, relCancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		if err := lease.Release(relCtx); err != nil {
+			s.log.Warn("run lock release failed", zap.String("task", run.Name), zap.Error(err))
+		}
+		relCancel()
+		s.mu.Lock()
+		e.running = false
+		s.mu.Unlock()
+	}()
+	cp := *run
+	return &cp
+}
+
+func (s *Scheduler) execute(ctx context.Context, e *entry, run *Run) {
+	ctx, span := s.tracer.Start(ctx, "cron "+run.Name, trace.WithAttributes(
+		attribute.String("cron.task", run.Name),
+		attribute.String("cron.trigger", run.Trigger),
+		attribute.String("cron.run_id", run.ID.String()),
+	))
+	defer span.End()
+
+	lg := s.log.With(
+		zap.String("task", run.Name),
+		zap.String("run_id", run.ID.String()),
+		zap.String("trigger", run.Trigger),
+	)
+	// bookkeeping must not depend on the task context
+	dbCtx := context.WithoutCancel(ctx)
+	if err := s.db.WithContext(dbCtx).Create(run).Error; err != nil {
+		lg.Error("record run failed", zap.Error(err))
+	}
+
+	err := safeRun(ctx, e.task)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMS = finished.Sub(run.StartedAt).Milliseconds()
+	run.Status = StatusSucceeded
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		lg.Error("task failed", zap.Int64("duration_ms", run.DurationMS), zap.Error(err))
+	} else {
+		lg.Info("task done", zap.Int64("duration_ms", run.DurationMS))
+	}
+	if err := s.db.WithContext(dbCtx).Save(run).Error; err != nil {
+		lg.Error("record run failed", zap.Error(err))
+	}
+}
+
+func safeRun(ctx context.Context, t Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return t.Run(ctx)
+}
+
+func (s *Scheduler) IsLeader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader
+}
+
+func (s *Scheduler) Tasks() []TaskInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]TaskInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, TaskInfo{Name: e.task.Name(), Spec: e.task.Spec(), Next: e.next, Running: e.running})
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out
+}
+
+// RecentRuns returns the latest runs of a task, newest first.
+func (s *Scheduler) RecentRuns(ctx context.Context, name string, limit int) ([]Run, error) {
+	var runs []Run
+	err := s.db.WithContext(ctx).
+		Where("name = ?", name).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+// instance identifies this process in leader keys and job_runs.
+var instance = func() string {
+	host, _ := os.Hostname()
+	return host + "-" + uuid.NewString()[:8]
+}()
+
+func RegisterHooks(lc fx.Lifecycle, s *Scheduler, cfg *config.Config, log *zap.Logger) {
+	if !cfg.SchedulerEnabled {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if len(s.entries) == 0 {
+				return nil
+			}
+			s.Start()
+			log.Info("scheduler started", zap.Int("tasks", len(s.entries)))
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopCtx, cancel := context.WithTimeout(ctx, cfg.GracefulTimeout)
+			defer cancel()
+			log.Info("stopping scheduler")
+			return s.Stop(stopCtx)
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Task is a periodic job. Spec accepts standard 5-field cron expressions and
+// descriptors such as "@hourly" or "@every 10m".
+type Task interface {
+	Name() string
+	Spec() string
+	Run(ctx context.Context) error
+}
+
+type taskFunc struct {
+	name string
+	spec string
+	fn   func(ctx context.Context) error
+}
+
+func (t taskFunc) Name() string                  { return t.name }
+func (t taskFunc) Spec() string                  { return t.spec }
+func (t taskFunc) Run(ctx context.Context) error { return t.fn(ctx) }
+
+func NewTask(name, spec string, fn func(ctx context.Context) error) Task {
+	return taskFunc{name: name, spec: spec, fn: fn}
+}
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Run is one execution of a task, stored in job_runs.
+type Run struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Trigger    string     `gorm:"not null" json:"trigger"`
+	Status     string     `gorm:"not null" json:"status"`
+	Instance   string     `gorm:"not null" json:"instance"`
+	Error      string     `gorm:"not null;default:''" json:"error,omitempty"`
+	StartedAt  time.Time  `gorm:"not null" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMS int64      `gorm:"not null;default:0" json:"duration_ms"`
+}
+
+func (Run) TableName() string { return "job_runs" }
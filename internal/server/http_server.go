@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"microseed/internal/config"
+	"microseed/internal/lifecycle"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
@@ -25,7 +26,7 @@ func NewHTTP(cfg *config.Config, r *gin.Engine, logger *zap.Logger) *HTTP {
 	return &HTTP{Srv: server}
 }
 
-func RegisterHooks(lc fx.Lifecycle, h *HTTP, cfg *config.Config, logger *zap.Logger) {
+func RegisterHooks(lc fx.Lifecycle, h *HTTP, cfg *config.Config, state *lifecycle.State, logger *zap.Logger) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {
@@ -39,8 +40,15 @@ func RegisterHooks(lc fx.Lifecycle, h *HTTP, cfg *config.Config, logger *zap.Log
 		OnStop: func(ctx context.Context) error {
 			shutdownCtx, cancel := context.WithTimeout(ctx, cfg.GracefulTimeout)
 			defer cancel()
-			logger.Info("shutting down http server", zap.Duration("timeout", cfg.GracefulTimeout))
-			return h.Srv.Shutdown(shutdownCtx)
+			logger.Info("shutting down http server",
+				zap.Duration("timeout", cfg.GracefulTimeout),
+				zap.Int64("inflight", state.InFlight()),
+			)
+			err := h.Srv.Shutdown(shutdownCtx)
+			if err != nil {
+				logger.Warn("http shutdown incomplete", zap.Int64("inflight", state.InFlight()), zap.Error(err))
+			}
+			return err
 		},
 	})
 }
@@ -0,0 +1,436 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"microseed/internal/config"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	readBlock = 2 * time.Second
+	claimSize = 100
+)
+
+// Consumer runs one reader per registered handler in the shared consumer
+// group. Entries are acked after a successful Handle; failed ones stay
+// pending and are re-claimed with XCLAIM once idle, from this or any
+// other (possibly dead) consumer. Entries delivered more than
+// maxDeliveries times go to the "<stream>:dead" stream.
+type Consumer struct {
+	rdb           redis.UniversalClient
+	log           *zap.Logger
+	tracer        trace.Tracer
+	group         string
+	name          string
+	concurrency   int
+	maxDeliveries int64
+	minIdle       time.Duration
+	claimEvery    time.Duration
+	redisRequired bool
+	handlers      []Handler
+
+	processed metric.Int64Counter
+	failures  metric.Int64Counter
+	dead      metric.Int64Counter
+
+	cancelRead context.CancelFunc
+	readers    sync.WaitGroup
+	inflight   sync.WaitGroup
+
+	mu     sync.Mutex
+	active map[string]struct{} // stream/id being handled here
+}
+
+type consumerIn struct {
+	fx.In
+
+	RDB      redis.UniversalClient
+	Cfg      *config.Config
+	Log      *zap.Logger
+	Handlers []Handler `group:"streams"`
+}
+
+func NewConsumer(in consumerIn) (*Consumer, error) {
+	host, _ := os.Hostname()
+	c := &Consumer{
+		rdb:           in.RDB,
+		log:           in.Log.Named("stream"),
+		tracer:        otel.Tracer("microseed/stream"),
+		group:         in.Cfg.StreamGroup,
+		name:          host + "-" + uuid.NewString()[:8],
+		concurrency:   max(in.Cfg.StreamConcurrency, 1),
+		maxDeliveries: in.Cfg.StreamMaxDeliveries,
+		minIdle:       in.Cfg.StreamClaimMinIdle,
+		claimEvery:    in.Cfg.StreamClaimInterval,
+		redisRequired: in.Cfg.RedisRequired,
+		handlers:      in.Handlers,
+		active:        map[string]struct{}{},
+	}
+
+	meter := otel.Meter("microseed/stream")
+	var err error
+	if c.processed, err = meter.Int64Counter("stream.processed"); err != nil {
+		return nil, err
+	}
+	if c.failures, err = meter.Int64Counter("stream.failures"); err != nil {
+		return nil, err
+	}
+	if c.dead, err = meter.Int64Counter("stream.dead_lettered"); err != nil {
+		return nil, err
+	}
+	lag, err := meter.Int64ObservableGauge("stream.lag",
+		metric.WithDescription("Entries not yet delivered to the consumer group"))
+	if err != nil {
+		return nil, err
+	}
+	pending, err := meter.Int64ObservableGauge("stream.pending",
+		metric.WithDescription("Delivered but unacknowledged entries"))
+	if err != nil {
+		return nil, err
+	}
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for _, h := range c.handlers {
+			groups, err := c.rdb.XInfoGroups(ctx, h.Stream()).Result()
+			if err != nil {
+				continue
+			}
+			for _, g := range groups {
+				if g.Name != c.group {
+					continue
+				}
+				attrs := metric.WithAttributes(attribute.String("stream", h.Stream()), attribute.String("group", g.Name))
+				o.ObserveInt64(lag, g.Lag, attrs)
+				o.ObserveInt64(pending, g.Pending, attrs)
+			}
+		}
+		return nil
+	}, lag, pending)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Consumer) Start(ctx context.Context) error {
+	for _, h := range c.handlers {
+		if err := c.ensureGroup(ctx, h.Stream()); err != nil {
+			if c.redisRequired {
+				return err
+			}
+			// readLoop creates it once Redis is reachable (NOGROUP)
+			c.log.Warn("create group failed", zap.String("stream", h.Stream()), zap.Error(err))
+		}
+	}
+
+	readCtx, cancel := context.WithCancel(context.Background())
+	c.cancelRead = cancel
+	for _, h := range c.handlers {
+		sem := make(chan struct{}, c.concurrency)
+		c.readers.Add(2)
+		go func() {
+			defer c.readers.Done()
+			c.readLoop(readCtx, h, sem)
+		}()
+		go func() {
+			defer c.readers.Done()
+			c.claimLoop(readCtx, h, sem)
+		}()
+	}
+	return nil
+}
+
+// Stop stops reading and waits for in-flight handlers; unfinished entries
+// stay pending and will be claimed by another consumer.
+func (c *Consumer) Stop(ctx context.Context) error {
+	if c.cancelRead == nil {
+		return nil
+	}
+	c.cancelRead()
+
+	// a blocked XREADGROUP only returns after readBlock
+	done := make(chan struct{})
+	go func() {
+		c.readers.Wait()
+		c.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stream: drain interrupted: %w", ctx.Err())
+	}
+}
+
+func (c *Consumer) ensureGroup(ctx context.Context, stream string) error {
+	err := c.rdb.XGroupCreateMkStream(ctx, stream, c.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("stream: create group %s/%s: %w", stream, c.group, err)
+	}
+	return nil
+}
+
+func (c *Consumer) readLoop(ctx context.Context, h Handler, sem chan struct{}) {
+	for ctx.Err() == nil {
+		// only ask for what we can run right now
+		free := cap(sem) - len(sem)
+		if free == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+				<-sem
+			}
+			continue
+		}
+		res, err := c.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.name,
+			Streams:  []string{h.Stream(), ">"},
+			Count:    int64(free),
+			Block:    readBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				if err := c.ensureGroup(ctx, h.Stream()); err == nil {
+					continue
+				}
+			}
+			c.log.Error("read failed", zap.String("stream", h.Stream()), zap.Error(err))
+			sleep(ctx, time.Second)
+			continue
+		}
+		for _, s := range res {
+			for _, xm := range s.Messages {
+				c.dispatch(ctx, h, sem, &Message{ID: xm.ID, Stream: h.Stream(), Values: xm.Values, Deliveries: 1})
+			}
+		}
+	}
+}
+
+func (c *Consumer) claimLoop(ctx context.Context, h Handler, sem chan struct{}) {
+	t := time.NewTicker(c.claimEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := c.claim(ctx, h, sem); err != nil && ctx.Err() == nil {
+			c.log.Error("claim failed", zap.String("stream", h.Stream()), zap.Error(err))
+		}
+	}
+}
+
+// claim takes over entries idle for minIdle. Entries this consumer is
+// still processing are skipped: a slow handler must not run twice here.
+func (c *Consumer) claim(ctx context.Context, h Handler, sem chan struct{}) error {
+	start := "-"
+	for {
+		pending, err := c.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: h.Stream(),
+			Group:  c.group,
+			Idle:   c.minIdle,
+			Start:  start,
+			End:    "+",
+			Count:  claimSize,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(pending))
+		retries := make(map[string]int64, len(pending))
+		for _, p := range pending {
+			if p.Consumer == c.name && c.isActive(h.Stream(), p.ID) {
+				continue
+			}
+			ids = append(ids, p.ID)
+			retries[p.ID] = p.RetryCount
+		}
+		if len(ids) > 0 {
+			msgs, err := c.rdb.XClaim(ctx, &redis.XClaimArgs{
+				Stream:   h.Stream(),
+				Group:    c.group,
+				Consumer: c.name,
+				MinIdle:  c.minIdle,
+				Messages: ids,
+			}).Result()
+			if err != nil {
+				return err
+			}
+			for _, xm := range msgs {
+				// XCLAIM counted this delivery
+				msg := &Message{ID: xm.ID, Stream: h.Stream(), Values: xm.Values, Deliveries: retries[xm.ID] + 1}
+				if c.maxDeliveries > 0 && msg.Deliveries > c.maxDeliveries {
+					c.deadLetter(ctx, msg)
+					continue
+				}
+				c.dispatch(ctx, h, sem, msg)
+			}
+		}
+		if len(pending) < claimSize || ctx.Err() != nil {
+			return nil
+		}
+		start = "(" + pending[len(pending)-1].ID
+	}
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, msg *Message) {
+	values := make(map[string]any, len(msg.Values)+3)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["dead_origin_id"] = msg.ID
+	values["dead_group"] = c.group
+	values["dead_deliveries"] = msg.Deliveries
+
+	pipe := c.rdb.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{Stream: msg.Stream + ":dead", Values: values})
+	pipe.XAck(ctx, msg.Stream, c.group, msg.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.log.Error("dead-letter failed", zap.String("stream", msg.Stream), zap.String("id", msg.ID), zap.Error(err))
+		return
+	}
+	c.dead.Add(ctx, 1, metric.WithAttributes(attribute.String("stream", msg.Stream)))
+	c.log.Warn("message dead-lettered",
+		zap.String("stream", msg.Stream),
+		zap.String("id", msg.ID),
+		zap.Int64("deliveries", msg.Deliveries),
+	)
+}
+
+func (c *Consumer) dispatch(ctx context.Context, h Handler, sem chan struct{}, msg *Message) {
+	select {
+	case <-ctx.Done():
+		return // stays pending, reclaimed later
+	case sem <- struct{}{}:
+	}
+	c.inflight.Add(1)
+	c.setActive(msg, true)
+	go func() {
+		defer func() {
+			c.setActive(msg, false)
+			<-sem
+			c.inflight.Done()
+		}()
+		// handlers finish even when reading stops
+		c.process(context.WithoutCancel(ctx), h, msg)
+	}()
+}
+
+func (c *Consumer) setActive(msg *Message, on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if on {
+		c.active[msg.Stream+"/"+msg.ID] = struct{}{}
+	} else {
+		delete(c.active, msg.Stream+"/"+msg.ID)
+	}
+}
+
+func (c *Consumer) isActive(stream, id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.active[stream+"/"+id]
+	return ok
+}
+
+func (c *Consumer) process(ctx context.Context, h Handler, msg *Message) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, messageCarrier(msg.Values))
+	ctx, span := c.tracer.Start(ctx, "consume "+msg.Stream,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", msg.Stream),
+			attribute.String("messaging.message.id", msg.ID),
+			attribute.String("messaging.consumer.group.name", c.group),
+			attribute.Int64("messaging.delivery_count", msg.Deliveries),
+		),
+	)
+	defer span.End()
+
+	attrs := metric.WithAttributes(attribute.String("stream", msg.Stream))
+	err := safeHandle(ctx, h, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.failures.Add(ctx, 1, attrs)
+		c.log.Warn("handler failed",
+			zap.String("stream", msg.Stream),
+			zap.String("id", msg.ID),
+			zap.Int64("deliveries", msg.Deliveries),
+			zap.Error(err),
+		)
+		return
+	}
+	if err := c.rdb.XAck(ctx, msg.Stream, c.group, msg.ID).Err(); err != nil {
+		c.log.Error("ack failed", zap.String("stream", msg.Stream), zap.String("id", msg.ID), zap.Error(err))
+		return
+	}
+	c.processed.Add(ctx, 1, attrs)
+}
+
+func safeHandle(ctx context.Context, h Handler, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h.Handle(ctx, msg)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func RegisterHooks(lc fx.Lifecycle, c *Consumer, cfg *config.Config, log *zap.Logger) {
+	if len(c.handlers) == 0 {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := c.Start(ctx); err != nil {
+				return err
+			}
+			log.Info("stream consumer started",
+				zap.String("group", c.group),
+				zap.String("consumer", c.name),
+				zap.Int("handlers", len(c.handlers)),
+			)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopCtx, cancel := context.WithTimeout(ctx, cfg.GracefulTimeout)
+			defer cancel()
+			log.Info("stopping stream consumer")
+			return c.Stop(stopCtx)
+		},
+	})
+}
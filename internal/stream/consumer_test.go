@@ -0,0 +1,116 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"microseed/internal/config"
+	"microseed/internal/testutil"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestConsumer(t *testing.T, h Handler, maxDeliveries int64) (*Consumer, redis.UniversalClient) {
+	t.Helper()
+	_, rdb := testutil.Redis(t)
+	c, err := NewConsumer(consumerIn{
+		RDB: rdb,
+		Cfg: &config.Config{
+			StreamGroup:         "test",
+			StreamConcurrency:   2,
+			StreamMaxDeliveries: maxDeliveries,
+			StreamClaimMinIdle:  20 * time.Millisecond,
+			StreamClaimInterval: 10 * time.Millisecond,
+		},
+		Log:      zap.NewNop(),
+		Handlers: []Handler{h},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.Stop(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+	return c, rdb
+}
+
+func publish(t *testing.T, rdb redis.UniversalClient, stream string) {
+	t.Helper()
+	if err := rdb.XAdd(context.Background(), &redis.XAddArgs{Stream: stream, Values: map[string]any{"k": "v"}}).Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClaimSkipsOwnInFlight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h := HandlerFunc("orders", func(ctx context.Context, msg *Message) error {
+		calls.Add(1)
+		<-release // outlives minIdle many times over
+		return nil
+	})
+	_, rdb := newTestConsumer(t, h, 0)
+	publish(t, rdb, "orders")
+
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times, want 1", n)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		p, err := rdb.XPending(context.Background(), "orders", "test").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d entries still pending", p.Count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClaimRetriesThenDeadLetters(t *testing.T) {
+	var calls atomic.Int32
+	h := HandlerFunc("orders", func(ctx context.Context, msg *Message) error {
+		if got, want := msg.Deliveries, int64(calls.Add(1)); got != want {
+			t.Errorf("Deliveries = %d, want %d", got, want)
+		}
+		return errors.New("boom")
+	})
+	_, rdb := newTestConsumer(t, h, 2)
+	publish(t, rdb, "orders")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n, err := rdb.XLen(context.Background(), "orders:dead").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("not dead-lettered after %d calls", calls.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler ran %d times, want 2", n)
+	}
+}
@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+)
+
+type Message struct {
+	ID         string
+	Stream     string
+	Values     map[string]any
+	Deliveries int64 // 1 on first delivery
+}
+
+// Get returns a field as string ("" when missing).
+func (m *Message) Get(key string) string {
+	v, ok := m.Values[key]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// Handler consumes one stream in the service's consumer group.
+type Handler interface {
+	Stream() string
+	Handle(ctx context.Context, msg *Message) error
+}
+
+type handlerFunc struct {
+	stream string
+	fn     func(ctx context.Context, msg *Message) error
+}
+
+func (h handlerFunc) Stream() string                                 { return h.stream }
+func (h handlerFunc) Handle(ctx context.Context, msg *Message) error { return h.fn(ctx, msg) }
+
+func HandlerFunc(stream string, fn func(ctx context.Context, msg *Message) error) Handler {
+	return handlerFunc{stream: stream, fn: fn}
+}
+
+// messageCarrier exposes message fields to the OTel propagator.
+type messageCarrier map[string]any
+
+func (c messageCarrier) Get(key string) string {
+	s, _ := c[key].(string)
+	return s
+}
+
+func (c messageCarrier) Set(key, value string) { c[key] = value }
+
+func (c messageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,14 @@
+package stream
+
+import "go.uber.org/fx"
+
+// Module provides the Producer.
+var Module = fx.Options(
+	fx.Provide(NewProducer),
+)
+
+// ConsumerModule runs handlers contributed with fx.ResultTags(`group:"streams"`).
+var ConsumerModule = fx.Options(
+	fx.Provide(NewConsumer),
+	fx.Invoke(RegisterHooks),
+)
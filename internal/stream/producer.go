@@ -0,0 +1,41 @@
+package stream
+
+import (
+	"context"
+
+	"microseed/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type Producer struct {
+	rdb    redis.UniversalClient
+	maxLen int64
+}
+
+func NewProducer(rdb redis.UniversalClient, cfg *config.Config) *Producer {
+	return &Producer{rdb: rdb, maxLen: cfg.StreamMaxLen}
+}
+
+// Publish appends values to stream (XADD MAXLEN ~ n) and returns the entry ID.
+// The caller's trace context travels in the traceparent/tracestate fields.
+func (p *Producer) Publish(ctx context.Context, stream string, values map[string]any) (string, error) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	fields := make(map[string]any, len(values)+len(carrier))
+	for k, v := range values {
+		fields[k] = v
+	}
+	for k, v := range carrier {
+		fields[k] = v
+	}
+
+	args := &redis.XAddArgs{Stream: stream, Values: fields}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+	return p.rdb.XAdd(ctx, args).Result()
+}
@@ -0,0 +1,79 @@
+// Package testutil holds setup shared by package tests: private in-memory
+// SQLite databases, miniredis servers and the optional Postgres at
+// TEST_DATABASE_DSN. Import it from _test.go files only.
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	sqlitedrv "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	// stores run Postgres SQL that calls now()
+	sqlitedrv.MustRegisterScalarFunction("now", 0, func(*sqlitedrv.FunctionContext, []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format("2006-01-02 15:04:05.999999999-07:00"), nil
+	})
+}
+
+// SQLite opens a private in-memory SQLite database, closed on cleanup. One
+// connection is held open so the database survives the pool discarding the
+// others.
+func SQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+uuid.NewString()+"?mode=memory&cache=shared"),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	keep, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = keep.Close() })
+	return db
+}
+
+// Redis starts a miniredis server and a client connected to it.
+func Redis(t testing.TB) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+// Postgres connects to TEST_DATABASE_DSN and skips the test when it is unset.
+func Postgres(t testing.TB) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
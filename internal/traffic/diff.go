@@ -0,0 +1,101 @@
+package traffic
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// Redacted replaces sensitive values in recordings; the diff treats it as
+// a wildcard.
+const Redacted = "[REDACTED]"
+
+// DefaultIgnore : fields that differ on every run, skipped at any depth.
+var DefaultIgnore = []string{"id", "request_id", "created_at", "updated_at", "deleted_at", "time", "timestamp", "secret"}
+
+const maxDiffs = 5
+
+// diffBody compares a recorded body with a replayed one. JSON is compared
+// structurally (ignored fields and redacted values skipped), anything
+// else byte for byte.
+func diffBody(want string, got []byte, ignore []string) []string {
+	if want == "" && len(got) == 0 {
+		return nil
+	}
+	var w, g any
+	if json.Unmarshal([]byte(want), &w) != nil {
+		if want != string(got) {
+			return []string{"body differs"}
+		}
+		return nil
+	}
+	if json.Unmarshal(got, &g) != nil {
+		return []string{"body: expected JSON"}
+	}
+	var out []string
+	diffJSON("$", w, g, ignore, &out)
+	if len(out) > maxDiffs {
+		out = append(out[:maxDiffs], fmt.Sprintf("… %d more", len(out)-maxDiffs))
+	}
+	return out
+}
+
+func diffJSON(path string, want, got any, ignore []string, out *[]string) {
+	if s, ok := want.(string); ok && s == Redacted {
+		return
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			*out = append(*out, path+": expected object")
+			return
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if slices.Contains(ignore, k) {
+				continue
+			}
+			wv, inW := w[k]
+			gv, inG := g[k]
+			switch {
+			case !inG:
+				*out = append(*out, path+"."+k+": missing")
+			case !inW:
+				*out = append(*out, path+"."+k+": unexpected")
+			default:
+				diffJSON(path+"."+k, wv, gv, ignore, out)
+			}
+		}
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			*out = append(*out, path+": expected array")
+			return
+		}
+		if len(w) != len(g) {
+			*out = append(*out, fmt.Sprintf("%s: length %d → %d", path, len(w), len(g)))
+			return
+		}
+		for i := range w {
+			diffJSON(path+"["+strconv.Itoa(i)+"]", w[i], g[i], ignore, out)
+		}
+	default:
+		if !reflect.DeepEqual(want, got) {
+			wj, _ := json.Marshal(want)
+			gj, _ := json.Marshal(got)
+			*out = append(*out, fmt.Sprintf("%s: %s → %s", path, wj, gj))
+		}
+	}
+}
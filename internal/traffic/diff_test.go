@@ -0,0 +1,40 @@
+package traffic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffBody(t *testing.T) {
+	cases := []struct {
+		name      string
+		want, got string
+		ignore    []string
+		diffs     []string
+	}{
+		{"equal", `{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, nil, nil},
+		{"empty", ``, ``, nil, nil},
+		{"value", `{"a":1}`, `{"a":2}`, nil, []string{"$.a: 1 → 2"}},
+		{"missing and unexpected", `{"a":1}`, `{"b":1}`, nil, []string{"$.a: missing", "$.b: unexpected"}},
+		{"nested ignore", `{"user":{"id":"x","email":"a"}}`, `{"user":{"id":"y","email":"a"}}`, DefaultIgnore, nil},
+		{"extra ignore", `{"n":1}`, `{"n":2}`, []string{"n"}, nil},
+		{"redacted wildcard", `{"token":"[REDACTED]"}`, `{"token":"abc"}`, nil, nil},
+		{"array length", `[1,2]`, `[1]`, nil, []string{"$: length 2 → 1"}},
+		{"array element", `[{"a":1}]`, `[{"a":true}]`, nil, []string{"$[0].a: 1 → true"}},
+		{"type", `{"a":{}}`, `{"a":[]}`, nil, []string{"$.a: expected object"}},
+		{"not json", `{"a":1}`, `oops`, nil, []string{"body: expected JSON"}},
+		{"text", `hello`, `hello`, nil, nil},
+		{"text differs", `hello`, `bye`, nil, []string{"body differs"}},
+		{"capped", `[1,2,3,4,5,6,7]`, `[0,0,0,0,0,0,0]`, nil, []string{
+			"$[0]: 1 → 0", "$[1]: 2 → 0", "$[2]: 3 → 0", "$[3]: 4 → 0", "$[4]: 5 → 0", "… 2 more",
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := diffBody(tc.want, []byte(tc.got), tc.ignore)
+			if !reflect.DeepEqual(got, tc.diffs) {
+				t.Errorf("diffBody = %q, want %q", got, tc.diffs)
+			}
+		})
+	}
+}
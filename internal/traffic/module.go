@@ -0,0 +1,9 @@
+package traffic
+
+import "go.uber.org/fx"
+
+// Module records HTTP traffic when TRAFFIC_RECORD_ENABLED=true (serve only).
+var Module = fx.Options(
+	fx.Provide(NewRecorder),
+	fx.Invoke(RegisterHooks),
+)
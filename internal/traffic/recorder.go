@@ -0,0 +1,115 @@
+package traffic
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"microseed/internal/config"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Exchange : one recorded request/response pair (a JSONL line).
+type Exchange struct {
+	Time            time.Time         `json:"time"`
+	RequestID       string            `json:"request_id,omitempty"`
+	Method          string            `json:"method"`
+	Route           string            `json:"route"`
+	Path            string            `json:"path"`
+	Query           string            `json:"query,omitempty"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	Status          int               `json:"status"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	LatencyMs       float64           `json:"latency_ms"`
+
+	// bodies that were truncated or of an unsupported type, or a request
+	// with redacted query/body values: the request can't be replayed /
+	// the response body can't be compared
+	RequestIncomplete  bool `json:"request_incomplete,omitempty"`
+	ResponseIncomplete bool `json:"response_incomplete,omitempty"`
+}
+
+// Recorder appends exchanges to a rotating JSONL file from a background
+// goroutine. Record never blocks a request: when the buffer is full the
+// exchange is dropped and counted.
+type Recorder struct {
+	out     *lumberjack.Logger
+	ch      chan *Exchange
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+	log     *zap.Logger
+}
+
+// NewRecorder returns nil when TRAFFIC_RECORD_ENABLED is false.
+func NewRecorder(cfg *config.Config, log *zap.Logger) *Recorder {
+	if !cfg.TrafficRecordEnabled {
+		return nil
+	}
+	return &Recorder{
+		out: &lumberjack.Logger{
+			Filename:   cfg.TrafficRecordFile,
+			MaxSize:    cfg.TrafficRecordMaxSizeMB,
+			MaxBackups: cfg.TrafficRecordMaxBackups,
+		},
+		ch:   make(chan *Exchange, 1024),
+		done: make(chan struct{}),
+		log:  log.Named("traffic"),
+	}
+}
+
+func (r *Recorder) Record(ex *Exchange) {
+	select {
+	case r.ch <- ex:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+func (r *Recorder) Start() {
+	go func() {
+		defer close(r.done)
+		enc := json.NewEncoder(r.out)
+		enc.SetEscapeHTML(false)
+		for ex := range r.ch {
+			if err := enc.Encode(ex); err != nil {
+				r.log.Warn("record failed", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// Stop flushes buffered exchanges; call after the HTTP server stopped.
+func (r *Recorder) Stop(ctx context.Context) error {
+	r.once.Do(func() { close(r.ch) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n := r.dropped.Load(); n > 0 {
+		r.log.Warn("exchanges dropped, recorder buffer full", zap.Int64("dropped", n))
+	}
+	return r.out.Close()
+}
+
+func RegisterHooks(lc fx.Lifecycle, r *Recorder, cfg *config.Config) {
+	if r == nil {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			r.Start()
+			r.log.Info("recording traffic", zap.String("file", cfg.TrafficRecordFile))
+			return nil
+		},
+		OnStop: r.Stop,
+	})
+}
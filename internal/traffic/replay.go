@@ -0,0 +1,259 @@
+package traffic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ReplayOptions struct {
+	Target      string        // base URL, e.g. http://localhost:8080
+	Concurrency int           // parallel requests (default 1)
+	Rate        float64       // requests per second, 0 = as fast as possible
+	Timeout     time.Duration // per request (default 10s)
+	Ignore      []string      // JSON fields skipped in the diff, at any depth
+	Headers     http.Header   // set on every request, e.g. real credentials
+	Out         io.Writer     // one line per mismatch, failure or skip
+}
+
+type Summary struct {
+	Total      int
+	Matched    int
+	Mismatched int
+	Failed     int // transport errors
+	Skipped    int // incomplete request or unreadable line
+	Elapsed    time.Duration
+
+	byRoute  map[string]int
+	recorded []time.Duration
+	replayed []time.Duration
+}
+
+// Ok : every replayed exchange matched its recording.
+func (s *Summary) Ok() bool { return s.Mismatched == 0 && s.Failed == 0 }
+
+// not copied from the recording: set by the client / server per request
+var skipHeaders = []string{"Content-Length", "Host", "Connection", "Accept-Encoding", "X-Request-Id", "Traceparent", "Tracestate"}
+
+type result struct {
+	ex      *Exchange
+	line    int
+	latency time.Duration
+	diffs   []string
+	err     error
+	skip    string
+}
+
+// Replay sends every exchange read from in (JSONL written by Recorder) to
+// opts.Target and compares status and body with the recording.
+func Replay(ctx context.Context, in io.Reader, opts ReplayOptions) (*Summary, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Out == nil {
+		opts.Out = io.Discard
+	}
+	target := strings.TrimRight(opts.Target, "/")
+	client := &http.Client{Timeout: opts.Timeout}
+
+	type job struct {
+		line int
+		ex   *Exchange
+		err  error
+	}
+	jobs := make(chan job)
+	results := make(chan result)
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opts.Rate > 0 {
+			t := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+			defer t.Stop()
+			tick = t.C
+		}
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for n := 1; sc.Scan(); n++ {
+			if len(strings.TrimSpace(sc.Text())) == 0 {
+				continue
+			}
+			var ex Exchange
+			err := json.Unmarshal(sc.Bytes(), &ex)
+			if err == nil && tick != nil && !ex.RequestIncomplete {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- job{line: n, ex: &ex, err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr = sc.Err()
+	}()
+
+	var wg sync.WaitGroup
+	for range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				switch {
+				case j.err != nil:
+					results <- result{line: j.line, skip: "unreadable line: " + j.err.Error()}
+				case j.ex.RequestIncomplete:
+					results <- result{line: j.line, ex: j.ex, skip: "request not recorded in full (truncated, unsupported or redacted)"}
+				default:
+					results <- replayOne(ctx, client, target, j.ex, j.line, opts)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	s := &Summary{byRoute: map[string]int{}}
+	for r := range results {
+		s.Total++
+		switch {
+		case r.skip != "":
+			s.Skipped++
+			fmt.Fprintf(opts.Out, "SKIP     line %d %s: %s\n", r.line, describe(r.ex), r.skip)
+			continue
+		case r.err != nil:
+			s.Failed++
+			s.byRoute[route(r.ex)]++
+			fmt.Fprintf(opts.Out, "FAIL     line %d %s: %v\n", r.line, describe(r.ex), r.err)
+			continue
+		}
+		s.recorded = append(s.recorded, time.Duration(r.ex.LatencyMs*float64(time.Millisecond)))
+		s.replayed = append(s.replayed, r.latency)
+		if len(r.diffs) == 0 {
+			s.Matched++
+			continue
+		}
+		s.Mismatched++
+		s.byRoute[route(r.ex)]++
+		fmt.Fprintf(opts.Out, "MISMATCH line %d %s\n", r.line, describe(r.ex))
+		for _, d := range r.diffs {
+			fmt.Fprintf(opts.Out, "         %s\n", d)
+		}
+	}
+	s.Elapsed = time.Since(start)
+	if readErr != nil {
+		return s, readErr
+	}
+	return s, ctx.Err()
+}
+
+func replayOne(ctx context.Context, client *http.Client, target string, ex *Exchange, line int, opts ReplayOptions) result {
+	u := target + ex.Path
+	if ex.Query != "" {
+		u += "?" + ex.Query
+	}
+	req, err := http.NewRequestWithContext(ctx, ex.Method, u, strings.NewReader(ex.RequestBody))
+	if err != nil {
+		return result{ex: ex, line: line, err: err}
+	}
+	for k, v := range ex.RequestHeaders {
+		if v == Redacted || slices.Contains(skipHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	for k, vs := range opts.Headers {
+		req.Header[k] = vs
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{ex: ex, line: line, err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return result{ex: ex, line: line, err: err}
+	}
+
+	r := result{ex: ex, line: line, latency: latency}
+	switch {
+	case resp.StatusCode != ex.Status:
+		// a different status makes a body diff noise
+		r.diffs = []string{fmt.Sprintf("status: %d → %d", ex.Status, resp.StatusCode)}
+	case !ex.ResponseIncomplete:
+		r.diffs = diffBody(ex.ResponseBody, body, opts.Ignore)
+	}
+	return r
+}
+
+// Print writes the summary in a human-readable form.
+func (s *Summary) Print(w io.Writer) {
+	fmt.Fprintf(w, "\nreplayed %d exchanges in %s\n", s.Total, s.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "  matched     %d\n", s.Matched)
+	fmt.Fprintf(w, "  mismatched  %d\n", s.Mismatched)
+	fmt.Fprintf(w, "  failed      %d\n", s.Failed)
+	fmt.Fprintf(w, "  skipped     %d\n", s.Skipped)
+	if len(s.replayed) > 0 {
+		fmt.Fprintf(w, "latency p50/p95  recorded %s / %s  replayed %s / %s\n",
+			pct(s.recorded, 50), pct(s.recorded, 95), pct(s.replayed, 50), pct(s.replayed, 95))
+	}
+	if len(s.byRoute) > 0 {
+		routes := make([]string, 0, len(s.byRoute))
+		for r := range s.byRoute {
+			routes = append(routes, r)
+		}
+		sort.Slice(routes, func(i, j int) bool {
+			if s.byRoute[routes[i]] != s.byRoute[routes[j]] {
+				return s.byRoute[routes[i]] > s.byRoute[routes[j]]
+			}
+			return routes[i] < routes[j]
+		})
+		fmt.Fprintln(w, "mismatches / failures by route:")
+		for _, r := range routes {
+			fmt.Fprintf(w, "  %4d  %s\n", s.byRoute[r], r)
+		}
+	}
+}
+
+func pct(ds []time.Duration, p int) time.Duration {
+	s := slices.Clone(ds)
+	slices.Sort(s)
+	i := (len(s) - 1) * p / 100
+	return s[i].Round(10 * time.Microsecond)
+}
+
+func route(ex *Exchange) string {
+	r := ex.Route
+	if r == "" {
+		r = ex.Path
+	}
+	return ex.Method + " " + r
+}
+
+func describe(ex *Exchange) string {
+	if ex == nil {
+		return ""
+	}
+	return ex.Method + " " + ex.Path
+}
@@ -0,0 +1,127 @@
+package traffic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func jsonl(t *testing.T, exs ...*Exchange) string {
+	t.Helper()
+	var b strings.Builder
+	for _, ex := range exs {
+		line, err := json.Marshal(ex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestReplay(t *testing.T) {
+	type seen struct {
+		method, path, query, body string
+		header                    http.Header
+	}
+	var mu sync.Mutex
+	var requests []seen
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, seen{r.Method, r.URL.Path, r.URL.RawQuery, string(b), r.Header.Clone()})
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/users":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id":"new","email":"a@example.com"}`)
+		case "/v1/users/1":
+			_, _ = io.WriteString(w, `{"id":"1","email":"changed@example.com"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"code":"not_found"}`)
+		}
+	}))
+	defer srv.Close()
+
+	in := jsonl(t,
+		&Exchange{Method: "POST", Path: "/v1/users", RequestBody: `{"email":"a@example.com"}`,
+			RequestHeaders: map[string]string{"Content-Type": "application/json", "Authorization": Redacted, "X-Request-Id": "r1"},
+			Status:         201, ResponseBody: `{"id":"old","email":"a@example.com"}`},
+		&Exchange{Method: "GET", Path: "/v1/users/1", Query: "expand=roles", Status: 200, ResponseBody: `{"id":"1","email":"a@example.com"}`},
+		&Exchange{Method: "GET", Path: "/v1/missing", Status: 200},
+		&Exchange{Method: "POST", Path: "/v1/login", RequestBody: `{"password":"[REDACTED]"}`, RequestIncomplete: true, Status: 200},
+	) + "not json\n"
+
+	var out bytes.Buffer
+	s, err := Replay(context.Background(), strings.NewReader(in), ReplayOptions{
+		Target:      srv.URL + "/",
+		Concurrency: 2,
+		Ignore:      DefaultIgnore,
+		Headers:     http.Header{"Authorization": {"Bearer real"}},
+		Out:         &out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Total != 5 || s.Matched != 1 || s.Mismatched != 2 || s.Failed != 0 || s.Skipped != 2 || s.Ok() {
+		t.Errorf("summary = %+v\n%s", s, out.String())
+	}
+	for _, want := range []string{
+		"MISMATCH line 2 GET /v1/users/1", `$.email: "a@example.com" → "changed@example.com"`,
+		"MISMATCH line 3 GET /v1/missing", "status: 200 → 404",
+		"SKIP     line 4 POST /v1/login",
+		"SKIP     line 5", "unreadable line",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("target saw %d requests, want 3 (incomplete ones are not sent)", len(requests))
+	}
+	for _, r := range requests {
+		if r.header.Get("Authorization") != "Bearer real" || r.header.Get("X-Request-Id") != "" {
+			t.Errorf("%s %s headers = %v", r.method, r.path, r.header)
+		}
+		switch r.path {
+		case "/v1/users":
+			if r.method != "POST" || r.body != `{"email":"a@example.com"}` || r.header.Get("Content-Type") != "application/json" {
+				t.Errorf("POST replayed as %+v", r)
+			}
+		case "/v1/users/1":
+			if r.query != "expand=roles" {
+				t.Errorf("query = %q", r.query)
+			}
+		}
+	}
+
+	var summary bytes.Buffer
+	s.Print(&summary)
+	if !strings.Contains(summary.String(), "mismatched  2") || !strings.Contains(summary.String(), "GET /v1/missing") {
+		t.Errorf("summary:\n%s", summary.String())
+	}
+}
+
+func TestReplayTransportFailure(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // nothing listens any more
+
+	s, err := Replay(context.Background(), strings.NewReader(jsonl(t, &Exchange{Method: "GET", Path: "/", Status: 200})),
+		ReplayOptions{Target: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Failed != 1 || s.Ok() {
+		t.Errorf("summary = %+v", s)
+	}
+}
@@ -0,0 +1,17 @@
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Exponential returns base*2^(attempt-1) capped at max, plus up to 20% jitter.
+// attempt starts at 1.
+func Exponential(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := time.Duration(math.Min(float64(base)*math.Pow(2, float64(attempt-1)), float64(max)))
+	return d + time.Duration(rand.Int64N(int64(d)/5+1))
+}